@@ -0,0 +1,45 @@
+package main
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+const adminTokenHeader = "X-Admin-Token"
+
+// requireAdminToken only lets requests through whose X-Admin-Token matches
+// token. An empty token disables the guarded endpoints entirely.
+func requireAdminToken(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		given := c.GetHeader(adminTokenHeader)
+		if token == "" || subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Invalid or missing admin token"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// @Summary	Delete all history
+// @Tags	history
+// @Produce	json
+// @Security	BearerAuth
+// @Param	X-Admin-Token	header	string	true	"Admin token"
+// @Success	200	{object}	clearHistoryResponse
+// @Failure	401	{object}	errorResponse
+// @Failure	403	{object}	errorResponse
+// @Failure	500	{object}	errorResponse
+// @Router	/history [delete]
+func clearHistory(c *gin.Context) {
+	ctx, cancel := dbContext(c)
+	defer cancel()
+
+	removed, err := historyRepo.Clear(ctx)
+	if err != nil {
+		handleDBError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "History cleared", "removed": removed})
+}
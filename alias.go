@@ -0,0 +1,132 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// fieldError is returned from UnmarshalJSON when a single field is at fault,
+// so bindErrors can report it under that field.
+type fieldError struct {
+	field   string
+	message string
+}
+
+func (e *fieldError) Error() string {
+	return fmt.Sprintf("%s %s", e.field, e.message)
+}
+
+// decodeMoney parses one price key, reporting a bad amount under field. The
+// json package does not say which field a custom unmarshaler failed on, so
+// prices are decoded here rather than directly into Money.
+func decodeMoney(field string, raw json.RawMessage) (*Money, error) {
+	if raw == nil || string(raw) == "null" {
+		return nil, nil
+	}
+	var m Money
+	if err := json.Unmarshal(raw, &m); err != nil {
+		return nil, &fieldError{field: field, message: errInvalidMoney.Error()}
+	}
+	return &m, nil
+}
+
+// resolvePrice picks the price from the resource-specific key or, for older
+// clients, the generic "price" key. Both may be sent only if they agree.
+func resolvePrice(field string, specificRaw json.RawMessage, genericRaw json.RawMessage) (Money, error) {
+	specific, err := decodeMoney(field, specificRaw)
+	if err != nil {
+		return 0, err
+	}
+	generic, err := decodeMoney("price", genericRaw)
+	if err != nil {
+		return 0, err
+	}
+	switch {
+	case specific != nil && generic != nil && *specific != *generic:
+		return 0, &fieldError{field: field, message: "conflicts with price"}
+	case specific != nil:
+		return *specific, nil
+	case generic != nil:
+		return *generic, nil
+	}
+	return 0, nil
+}
+
+func (t *Train) UnmarshalJSON(data []byte) error {
+	type train Train
+	aux := struct {
+		*train
+		TrainPrice json.RawMessage `json:"train_price"`
+		Price      json.RawMessage `json:"price"`
+	}{train: (*train)(t)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	price, err := resolvePrice("train_price", aux.TrainPrice, aux.Price)
+	t.Price = price
+	return err
+}
+
+func (p *Plane) UnmarshalJSON(data []byte) error {
+	type plane Plane
+	aux := struct {
+		*plane
+		PlanePrice json.RawMessage `json:"plane_price"`
+		Price      json.RawMessage `json:"price"`
+	}{plane: (*plane)(p)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	price, err := resolvePrice("plane_price", aux.PlanePrice, aux.Price)
+	p.Price = price
+	return err
+}
+
+func (b *Bus) UnmarshalJSON(data []byte) error {
+	type bus Bus
+	aux := struct {
+		*bus
+		BusPrice json.RawMessage `json:"bus_price"`
+		Price    json.RawMessage `json:"price"`
+	}{bus: (*bus)(b)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	price, err := resolvePrice("bus_price", aux.BusPrice, aux.Price)
+	b.Price = price
+	return err
+}
+
+func (h *History) UnmarshalJSON(data []byte) error {
+	type history History
+	aux := struct {
+		*history
+		HistoryPrice json.RawMessage `json:"history_price"`
+		Price        json.RawMessage `json:"price"`
+	}{history: (*history)(h)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	price, err := resolvePrice("history_price", aux.HistoryPrice, aux.Price)
+	h.Price = price
+	return err
+}
+
+func (p *TrainPatch) UnmarshalJSON(data []byte) error {
+	type trainPatch TrainPatch
+	aux := struct {
+		*trainPatch
+		Price json.RawMessage `json:"train_price"`
+	}{trainPatch: (*trainPatch)(p)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	price, err := decodeMoney("train_price", aux.Price)
+	p.Price = price
+	return err
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const claimsKey = "claims"
+
+type authClaims struct {
+	Role string `json:"role"`
+	jwt.RegisteredClaims
+}
+
+// authMiddleware requires a valid HS256 bearer token signed with secret and
+// stores its claims in the context under claimsKey.
+func authMiddleware(secret string) gin.HandlerFunc {
+	if secret == "" {
+		log.Println("WARNING: JWT_SECRET is not set; all write requests will be rejected")
+	}
+
+	return func(c *gin.Context) {
+		if secret == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authentication is not configured"})
+			return
+		}
+
+		header := c.GetHeader("Authorization")
+		tokenString, ok := strings.CutPrefix(header, "Bearer ")
+		if !ok || tokenString == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Missing bearer token"})
+			return
+		}
+
+		claims, message := parseToken(secret, tokenString)
+		if claims == nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": message})
+			return
+		}
+
+		c.Set(claimsKey, claims)
+		c.Next()
+	}
+}
+
+// optionalAuth is authMiddleware for public routes whose answer depends on
+// who is asking: requests without a bearer token go through anonymously, but
+// a token that is sent must be valid.
+func optionalAuth(secret string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tokenString, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if secret == "" || !ok || tokenString == "" {
+			c.Next()
+			return
+		}
+
+		claims, message := parseToken(secret, tokenString)
+		if claims == nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": message})
+			return
+		}
+
+		c.Set(claimsKey, claims)
+		c.Next()
+	}
+}
+
+// parseToken verifies an HS256 token, returning its claims or nil and the
+// message to answer 401 with.
+func parseToken(secret string, tokenString string) (*authClaims, string) {
+	claims := &authClaims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(*jwt.Token) (interface{}, error) {
+		return []byte(secret), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if errors.Is(err, jwt.ErrTokenExpired) {
+		return nil, "Token has expired"
+	}
+	if err != nil {
+		return nil, "Invalid token"
+	}
+	return claims, ""
+}
+
+// requireRole must run after authMiddleware. Authenticated callers without the
+// role get 403 rather than 401 since logging in again will not help.
+func requireRole(role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := c.Get(claimsKey)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Missing bearer token"})
+			return
+		}
+		if claims.(*authClaims).Role != role {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "This action requires the " + role + " role"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// hasRole reports whether the authenticated token, if any, carries role.
+func hasRole(c *gin.Context, role string) bool {
+	claims, ok := c.Get(claimsKey)
+	return ok && claims.(*authClaims).Role == role
+}
+
+// currentUser returns the subject of the authenticated token, if any.
+func currentUser(c *gin.Context) string {
+	if claims, ok := c.Get(claimsKey); ok {
+		return claims.(*authClaims).Subject
+	}
+	return ""
+}
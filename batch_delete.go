@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type batchDeleteRequest struct {
+	IDs []int64 `json:"ids" binding:"required,min=1,max=500,dive,gt=0,lte=2147483647"`
+}
+
+// @Summary	Soft-delete several trains
+// @Tags	trains
+// @Accept	json
+// @Produce	json
+// @Security	BearerAuth
+// @Param	ids	body	batchDeleteRequest	true	"Ids to delete"
+// @Success	200	{object}	batchDeleteResponse
+// @Failure	400	{object}	validationErrorResponse
+// @Failure	415	{object}	errorResponse
+// @Failure	401	{object}	errorResponse
+// @Failure	403	{object}	errorResponse
+// @Failure	500	{object}	errorResponse
+// @Router	/trains/delete-batch [post]
+func deleteTrainBatch(c *gin.Context) {
+	deleteBatch(c, trainRepo.DeleteBatch, trainCache, trainEvents)
+}
+
+// @Summary	Soft-delete several planes
+// @Tags	planes
+// @Accept	json
+// @Produce	json
+// @Security	BearerAuth
+// @Param	ids	body	batchDeleteRequest	true	"Ids to delete"
+// @Success	200	{object}	batchDeleteResponse
+// @Failure	400	{object}	validationErrorResponse
+// @Failure	415	{object}	errorResponse
+// @Failure	401	{object}	errorResponse
+// @Failure	403	{object}	errorResponse
+// @Failure	500	{object}	errorResponse
+// @Router	/planes/delete-batch [post]
+func deletePlaneBatch(c *gin.Context) {
+	deleteBatch(c, planeRepo.DeleteBatch, planeCache, nil)
+}
+
+// deleteBatch soft-deletes the requested ids in a single statement, so either
+// every live row is deleted or none are.
+func deleteBatch(c *gin.Context, del func(context.Context, []int64) ([]int64, error), cache *listCache, events *broadcaster) {
+	var req batchDeleteRequest
+	if !bindAndValidate(c, &req) {
+		return
+	}
+
+	ctx, cancel := dbContext(c)
+	defer cancel()
+
+	deleted, err := del(ctx, req.IDs)
+	if err != nil {
+		handleDBError(c, err)
+		return
+	}
+
+	found := make(map[int64]bool, len(deleted))
+	for _, id := range deleted {
+		found[id] = true
+	}
+	notFound := []int64{}
+	for _, id := range req.IDs {
+		if !found[id] {
+			notFound = append(notFound, id)
+			found[id] = true
+		}
+	}
+
+	if len(deleted) > 0 {
+		cache.invalidate()
+	}
+	for _, id := range deleted {
+		events.publish(changeEvent{Action: "deleted", ID: uint64(id)})
+	}
+	c.JSON(http.StatusOK, gin.H{"deleted": len(deleted), "not_found": notFound})
+}
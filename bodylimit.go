@@ -0,0 +1,37 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// bodyLimit caps request bodies at limit bytes. Requests that declare a larger
+// Content-Length are refused up front; chunked bodies hit the cap while
+// binding, which respondBindError turns into the same 413.
+func bodyLimit(limit int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.ContentLength > limit {
+			abortTooLarge(c, limit)
+			return
+		}
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+		c.Next()
+	}
+}
+
+func abortTooLarge(c *gin.Context, limit int64) {
+	c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("request body must be at most %d bytes", limit)})
+}
+
+// respondBindError writes the response for a failed ShouldBindJSON.
+func respondBindError(c *gin.Context, err error) {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		abortTooLarge(c, tooLarge.Limit)
+		return
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"errors": bindErrors(err)})
+}
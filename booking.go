@@ -0,0 +1,65 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary	Book a train
+// @Tags	trains
+// @Produce	json
+// @Security	BearerAuth
+// @Param	id	path	int	true	"Train id"
+// @Success	201	{object}	bookingResponse
+// @Failure	400	{object}	errorResponse
+// @Failure	401	{object}	errorResponse
+// @Failure	404	{object}	errorResponse
+// @Failure	500	{object}	errorResponse
+// @Router	/trains/{id}/book [post]
+func bookTrain(c *gin.Context) {
+	bookItem(c, "train", "trains")
+}
+
+// @Summary	Book a plane
+// @Tags	planes
+// @Produce	json
+// @Security	BearerAuth
+// @Param	id	path	int	true	"Plane id"
+// @Success	201	{object}	bookingResponse
+// @Failure	400	{object}	errorResponse
+// @Failure	401	{object}	errorResponse
+// @Failure	404	{object}	errorResponse
+// @Failure	500	{object}	errorResponse
+// @Router	/planes/{id}/book [post]
+func bookPlane(c *gin.Context) {
+	bookItem(c, "plane", "planes")
+}
+
+// bookItem runs behind transactional, so the booking and its history entry
+// commit together.
+func bookItem(c *gin.Context, kind string, table string) {
+	label := strings.ToUpper(kind[:1]) + kind[1:]
+
+	id, ok := parseID(c, kind)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := dbContext(c)
+	defer cancel()
+
+	booking, history, err := bookingRepo.WithTx(txFrom(c)).Book(ctx, kind, table, id)
+	if errors.Is(err, ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": label + " not found"})
+		return
+	}
+	if err != nil {
+		handleDBError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"booking": booking, "history": history})
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+type BookingRepository struct {
+	db dbtx
+}
+
+func NewBookingRepository(db *timedDB) *BookingRepository {
+	return &BookingRepository{db: db}
+}
+
+// WithTx returns a copy of the repository that runs its queries on tx.
+func (r *BookingRepository) WithTx(tx *timedTx) *BookingRepository {
+	return &BookingRepository{db: tx}
+}
+
+// Book records a booking for the item with the given id in table and appends
+// the matching history entry. It must run on a transaction (see WithTx) for
+// the two inserts to land together. kind is the column prefix of table
+// ("train" or "plane").
+func (r *BookingRepository) Book(ctx context.Context, kind string, table string, id uint64) (Booking, History, error) {
+	var name string
+	var price Money
+	err := r.db.QueryRowContext(ctx, "SELECT "+kind+"_name, "+kind+"_price FROM "+table+" WHERE "+kind+"_id=$1 AND deleted_at IS NULL FOR SHARE", id).Scan(&name, &price)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Booking{}, History{}, ErrNotFound
+	}
+	if err != nil {
+		return Booking{}, History{}, err
+	}
+
+	var booking Booking
+	err = r.db.QueryRowContext(ctx, "INSERT INTO bookings (booking_type, item_id, booking_name, booking_price) VALUES ($1, $2, $3, $4) RETURNING booking_id, booking_type, item_id, booking_name, booking_price, created_at", kind, id, name, price).
+		Scan(&booking.ID, &booking.Type, &booking.ItemID, &booking.Name, &booking.Price, &booking.CreatedAt)
+	if err != nil {
+		return Booking{}, History{}, err
+	}
+
+	history, err := scanHistory(r.db.QueryRowContext(ctx, "INSERT INTO history (history_name, history_price) VALUES ($1, $2) RETURNING "+historyColumns, name, price))
+	if err != nil {
+		return Booking{}, History{}, err
+	}
+	return booking, history, nil
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	breakerClosed   = "closed"
+	breakerOpen     = "open"
+	breakerHalfOpen = "half-open"
+)
+
+// circuitBreaker stops sending requests to a database that keeps failing.
+// After threshold consecutive outage errors it opens, and requests are
+// turned away with 503 until cooldown has passed. It is then half-open: a
+// single probe request is let through, and its first query decides whether
+// the breaker closes again or reopens for another cooldown. A zero threshold
+// disables it.
+type circuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu       sync.Mutex
+	state    string
+	failures int
+	openedAt time.Time
+	// probing is set while the half-open probe is in flight, so requests
+	// arriving meanwhile are still turned away.
+	probing bool
+}
+
+func newCircuitBreaker(threshold int, cooldown time.Duration) *circuitBreaker {
+	return &circuitBreaker{threshold: threshold, cooldown: cooldown, state: breakerClosed}
+}
+
+// dbBreaker guards every statement run through timedDB. main replaces it
+// with the configured one.
+var dbBreaker = newCircuitBreaker(0, 0)
+
+// allow reports whether a request may use the database, moving an open
+// breaker whose cooldown has passed to half-open.
+func (b *circuitBreaker) allow() bool {
+	allowed, _ := b.admit()
+	return allowed
+}
+
+// admit is allow that also reports whether the caller became the half-open
+// probe, which must be followed by a record or endProbe.
+func (b *circuitBreaker) admit() (allowed bool, probe bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == breakerOpen && time.Since(b.openedAt) >= b.cooldown {
+		b.state = breakerHalfOpen
+	}
+	switch {
+	case b.state == breakerClosed:
+		return true, false
+	case b.state == breakerHalfOpen && !b.probing:
+		b.probing = true
+		return true, true
+	}
+	return false, false
+}
+
+// endProbe frees the half-open slot after a probe request that never reached
+// the database, e.g. one rejected by validation, so the next one can probe.
+func (b *circuitBreaker) endProbe() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+// record feeds the outcome of one database call into the breaker. Errors
+// that don't point at an outage, such as constraint violations, count as
+// the database answering; calls the client cancelled say nothing either way.
+func (b *circuitBreaker) record(err error) {
+	if b.threshold == 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+	if errors.Is(err, context.Canceled) {
+		return
+	}
+	if !isOutage(err) {
+		b.state = breakerClosed
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.state == breakerHalfOpen || b.failures >= b.threshold {
+		b.state = breakerOpen
+		b.openedAt = time.Now()
+	}
+}
+
+// status returns the state and, while open, how long until the next probe.
+func (b *circuitBreaker) status() (state string, retryIn time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == breakerOpen {
+		retryIn = b.cooldown - time.Since(b.openedAt)
+	}
+	return b.state, max(retryIn, 0)
+}
+
+// guard answers 503 straight away while the breaker is open, or half-open
+// with its probe in flight, instead of letting the request wait on a
+// database that is known to be down.
+func (b *circuitBreaker) guard() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		allowed, probe := b.admit()
+		if !allowed {
+			_, retryIn := b.status()
+			c.Header("Retry-After", strconv.Itoa(max(int(math.Ceil(retryIn.Seconds())), 1)))
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "Database unavailable, try again later"})
+			return
+		}
+		if probe {
+			defer b.endProbe()
+		}
+		c.Next()
+	}
+}
+
+// isOutage reports whether err means the database could not be reached or
+// did not answer in time, as opposed to rejecting a particular query.
+func isOutage(err error) bool {
+	return err != nil && (isConnectionLost(err) || errors.Is(err, context.DeadlineExceeded))
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+const maxBulkInsert = 500
+
+// @Summary	Create several trains
+// @Tags	trains
+// @Accept	json
+// @Produce	json
+// @Security	BearerAuth
+// @Param	trains	body	[]Train	true	"Trains to create (max 500)"
+// @Param	Idempotency-Key	header	string	false	"Replay protection key"
+// @Success	201	{object}	bulkInsertResponse
+// @Failure	400	{object}	validationErrorResponse
+// @Failure	415	{object}	errorResponse
+// @Failure	401	{object}	errorResponse
+// @Failure	409	{object}	errorResponse
+// @Failure	422	{object}	errorResponse
+// @Failure	500	{object}	errorResponse
+// @Router	/trains/bulk [post]
+func bulkInsertTrains(c *gin.Context) {
+	trains, ok := bindTrainBatch(c, "inserted")
+	if !ok {
+		return
+	}
+
+	ctx, cancel := dbContext(c)
+	defer cancel()
+
+	inserted, err := trainRepo.InsertBatch(ctx, trains)
+	if err != nil {
+		handleDBError(c, err)
+		return
+	}
+
+	trainCache.invalidate()
+	trainEvents.publish(changeEvent{Action: "bulk"})
+	c.JSON(http.StatusCreated, gin.H{"inserted": inserted})
+}
+
+// @Summary	Import trains by name
+// @Description	Creates trains whose name is new and updates the price and currency of those that exist, all in one transaction. Soft-deleted trains are not matched, so a name that belongs to one creates a new train.
+// @Tags	trains
+// @Accept	json
+// @Produce	json
+// @Security	BearerAuth
+// @Param	trains	body	[]Train	true	"Trains to import (max 500)"
+// @Param	Idempotency-Key	header	string	false	"Replay protection key"
+// @Success	200	{object}	ImportResult
+// @Failure	400	{object}	validationErrorResponse
+// @Failure	415	{object}	errorResponse
+// @Failure	401	{object}	errorResponse
+// @Failure	422	{object}	errorResponse
+// @Failure	500	{object}	errorResponse
+// @Router	/trains/import [post]
+func importTrains(c *gin.Context) {
+	trains, ok := bindTrainBatch(c, "imported")
+	if !ok {
+		return
+	}
+
+	ctx, cancel := dbContext(c)
+	defer cancel()
+
+	result, err := trainRepo.Import(ctx, trains)
+	if err != nil {
+		handleDBError(c, err)
+		return
+	}
+
+	trainCache.invalidate()
+	trainEvents.publish(changeEvent{Action: "bulk"})
+	c.JSON(http.StatusOK, result)
+}
+
+// bindTrainBatch decodes and validates a JSON array of trains, writing a 400
+// that names the first bad element. verb only appears in the size error.
+func bindTrainBatch(c *gin.Context, verb string) ([]Train, bool) {
+	var trains []Train
+	// Decode without gin's binding so the tags can be checked per element and
+	// the failing index reported.
+	if err := json.NewDecoder(c.Request.Body).Decode(&trains); err != nil {
+		respondBindError(c, err)
+		return nil, false
+	}
+
+	if len(trains) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "at least one train is required"})
+		return nil, false
+	}
+	if len(trains) > maxBulkInsert {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("at most %d trains can be %s at once", maxBulkInsert, verb)})
+		return nil, false
+	}
+
+	for i, train := range trains {
+		if errs := validateItem(train); len(errs) > 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("trains[%d] is invalid", i), "index": i, "errors": errs})
+			return nil, false
+		}
+	}
+	return trains, true
+}
@@ -0,0 +1,37 @@
+package main
+
+import "database/sql"
+
+const busColumns = "bus_id, bus_name, bus_price, currency, version, created_at, deleted_at"
+
+var busSortOrders = catalogSortOrders("bus")
+
+var busTable = catalogTable[Bus]{
+	table:   "buses",
+	kind:    "bus",
+	columns: busColumns,
+	scan:    scanBus,
+	values: func(b Bus) (string, Money, string) {
+		return b.Name, b.Price, b.Currency
+	},
+	version: func(b Bus) uint { return b.Version },
+	id:      func(b Bus) uint64 { return uint64(b.ID) },
+}
+
+type BusRepository struct {
+	*catalogRepository[Bus]
+}
+
+func NewBusRepository(db *timedDB) *BusRepository {
+	return &BusRepository{&catalogRepository[Bus]{db: db, t: busTable}}
+}
+
+func scanBus(row rowScanner) (Bus, error) {
+	var bus Bus
+	var deletedAt sql.NullTime
+	err := row.Scan(&bus.ID, &bus.Name, &bus.Price, &bus.Currency, &bus.Version, &bus.CreatedAt, &deletedAt)
+	if deletedAt.Valid {
+		bus.DeletedAt = &deletedAt.Time
+	}
+	return bus, err
+}
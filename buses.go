@@ -0,0 +1,75 @@
+package main
+
+import "github.com/gin-gonic/gin"
+
+// @Summary	List buses
+// @Tags	buses
+// @Produce	json,application/xml
+// @Param	limit	query	int	false	"Page size (max 200)"
+// @Param	offset	query	int	false	"Rows to skip"
+// @Param	after	query	int	false	"Cursor: return the rows after this id instead of using offset; the response carries next_cursor"
+// @Param	min_price	query	number	false	"Lowest price"
+// @Param	max_price	query	number	false	"Highest price"
+// @Param	search	query	string	false	"Substring of the name"
+// @Param	sort	query	string	false	"Sort order: id_asc, id_desc, price_asc, price_desc, name_asc or name_desc"
+// @Param	include_deleted	query	bool	false	"Include soft-deleted buses; admins only"
+// @Param	stream	query	bool	false	"Stream every matching row as a JSON array, ignoring paging"
+// @Param	fields	query	string	false	"Comma-separated JSON fields to return; the id is always included, e.g. bus_name,bus_price"
+// @Success	200	{object}	Page{data=[]Bus}
+// @Success	304	"Not modified"
+// @Failure	400	{object}	errorResponse
+// @Failure	401	{object}	errorResponse
+// @Failure	403	{object}	errorResponse
+// @Failure	500	{object}	errorResponse
+// @Router	/buses [get]
+func getAllBuses(c *gin.Context) {
+	busHandlers.list(c)
+}
+
+// @Summary	Get a bus
+// @Tags	buses
+// @Produce	json
+// @Param	id	path	int	true	"Bus id"
+// @Success	200	{object}	Bus
+// @Failure	400	{object}	errorResponse
+// @Failure	404	{object}	errorResponse
+// @Failure	500	{object}	errorResponse
+// @Router	/buses/{id} [get]
+func getBusByID(c *gin.Context) {
+	busHandlers.get(c)
+}
+
+// @Summary	Create a bus
+// @Tags	buses
+// @Accept	json
+// @Produce	json
+// @Security	BearerAuth
+// @Param	bus	body	Bus	true	"Bus to create"
+// @Param	Idempotency-Key	header	string	false	"Replay protection key"
+// @Success	201	{object}	Bus
+// @Failure	400	{object}	validationErrorResponse
+// @Failure	415	{object}	errorResponse
+// @Failure	401	{object}	errorResponse
+// @Failure	409	{object}	errorResponse
+// @Failure	422	{object}	errorResponse
+// @Failure	500	{object}	errorResponse
+// @Router	/buses/add [post]
+func insertBus(c *gin.Context) {
+	busHandlers.insert(c)
+}
+
+// @Summary	Soft-delete a bus
+// @Tags	buses
+// @Produce	json
+// @Security	BearerAuth
+// @Param	id	path	int	true	"Bus id"
+// @Success	200	{object}	messageResponse
+// @Failure	400	{object}	errorResponse
+// @Failure	401	{object}	errorResponse
+// @Failure	403	{object}	errorResponse
+// @Failure	404	{object}	errorResponse
+// @Failure	500	{object}	errorResponse
+// @Router	/buses/{id} [delete]
+func deleteBus(c *gin.Context) {
+	busHandlers.delete(c)
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxCacheEntries bounds how many distinct queries a listCache holds.
+const maxCacheEntries = 1000
+
+const cacheStatusKey = "cache"
+
+type cacheEntry struct {
+	page    Page
+	expires time.Time
+}
+
+// listCache keeps recent list responses keyed by their normalised query
+// string. Writes to the resource clear it via invalidate.
+type listCache struct {
+	mu      sync.RWMutex
+	ttl     time.Duration
+	entries map[string]cacheEntry
+	// gen counts invalidations, so a page read before a write can't be
+	// stored after the write has cleared the cache.
+	gen uint64
+}
+
+func newListCache(ttl time.Duration) *listCache {
+	return &listCache{ttl: ttl, entries: make(map[string]cacheEntry)}
+}
+
+func cacheKey(c *gin.Context) string {
+	return c.Request.URL.Query().Encode()
+}
+
+// get looks up the page for this request and records hit or miss on the
+// context for the access log.
+func (lc *listCache) get(c *gin.Context) (Page, bool) {
+	if lc.ttl <= 0 {
+		return Page{}, false
+	}
+
+	lc.mu.RLock()
+	entry, ok := lc.entries[cacheKey(c)]
+	lc.mu.RUnlock()
+
+	if ok && time.Now().Before(entry.expires) {
+		c.Set(cacheStatusKey, "hit")
+		return entry.page, true
+	}
+	c.Set(cacheStatusKey, "miss")
+	return Page{}, false
+}
+
+// generation is read before querying the rows a page is built from and
+// passed to set.
+func (lc *listCache) generation() uint64 {
+	lc.mu.RLock()
+	defer lc.mu.RUnlock()
+	return lc.gen
+}
+
+// set stores page unless the cache was invalidated since gen was read, in
+// which case the page may predate the write and is dropped.
+func (lc *listCache) set(c *gin.Context, gen uint64, page Page) {
+	if lc.ttl <= 0 {
+		return
+	}
+
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+	if gen != lc.gen {
+		return
+	}
+
+	now := time.Now()
+	if len(lc.entries) >= maxCacheEntries {
+		for key, entry := range lc.entries {
+			if now.After(entry.expires) {
+				delete(lc.entries, key)
+			}
+		}
+		if len(lc.entries) >= maxCacheEntries {
+			lc.entries = make(map[string]cacheEntry)
+		}
+	}
+	lc.entries[cacheKey(c)] = cacheEntry{page: page, expires: now.Add(lc.ttl)}
+}
+
+func (lc *listCache) invalidate() {
+	lc.mu.Lock()
+	lc.entries = make(map[string]cacheEntry)
+	lc.gen++
+	lc.mu.Unlock()
+}
@@ -0,0 +1,338 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// catalogHandlers implements the endpoints that trains and planes share. The
+// named handlers registered in main are thin wrappers so each route keeps its
+// own API documentation.
+type catalogHandlers[T any] struct {
+	kind  string
+	repo  *catalogRepository[T]
+	cache *listCache
+	// sortOrders whitelists ?sort= values; when nil the parameter is ignored
+	// and rows come back in defaultSort order.
+	sortOrders map[string]string
+	// defaultSort is the ORDER BY clause used without ?sort=. It always ends
+	// in the id so pages are stable.
+	defaultSort string
+	// events receives a changeEvent for each write; nil means nobody listens.
+	events *broadcaster
+}
+
+var (
+	trainHandlers *catalogHandlers[Train]
+	planeHandlers *catalogHandlers[Plane]
+	busHandlers   *catalogHandlers[Bus]
+)
+
+func (h *catalogHandlers[T]) label() string {
+	return strings.ToUpper(h.kind[:1]) + h.kind[1:]
+}
+
+func (h *catalogHandlers[T]) notFound(c *gin.Context) {
+	c.JSON(http.StatusNotFound, gin.H{"error": h.label() + " not found"})
+}
+
+func (h *catalogHandlers[T]) conflict(c *gin.Context) {
+	c.JSON(http.StatusConflict, gin.H{"error": "a " + h.kind + " with that name already exists"})
+}
+
+func (h *catalogHandlers[T]) list(c *gin.Context) {
+	limit, offset, err := parsePagination(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	stream, err := parseStream(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	fields, err := parseFields(c, jsonFields[T](), h.repo.column("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	// Picked fields are maps, which encoding/xml can't write.
+	if fields != nil && !stream && wantsXML(c) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "fields is only supported for JSON responses"})
+		return
+	}
+
+	after, err := parseCursor(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if after != nil && c.Query("sort") != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "after pages in id order and cannot be combined with sort"})
+		return
+	}
+
+	includeDeleted, err := parseIncludeDeleted(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if includeDeleted && !hasRole(c, "admin") {
+		c.JSON(http.StatusForbidden, gin.H{"error": "include_deleted requires the admin role"})
+		return
+	}
+
+	// Admin views of deleted rows stay out of the cache shared with everyone.
+	cacheable := !stream && after == nil && !includeDeleted
+	if cacheable {
+		if page, ok := h.cache.get(c); ok {
+			setPaginationHeaders(c, page.Total, page.Limit, page.Offset)
+			respondWithETag(c, page)
+			return
+		}
+	}
+
+	lower, upper, err := parsePriceRange(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	order := h.defaultSort
+	if h.sortOrders != nil {
+		order, err = parseSort(c, h.sortOrders, h.defaultSort)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	filter := CatalogFilter{
+		MinPrice:       lower,
+		MaxPrice:       upper,
+		Search:         c.Query("search"),
+		IncludeDeleted: includeDeleted,
+		Order:          order,
+		Limit:          limit,
+		Offset:         offset,
+		Fields:         fields,
+	}
+
+	if stream {
+		h.stream(c, filter)
+		return
+	}
+	if after != nil {
+		h.listAfter(c, filter, *after)
+		return
+	}
+
+	ctx, cancel := dbContext(c)
+	defer cancel()
+
+	gen := h.cache.generation()
+	items, total, err := h.repo.List(ctx, filter)
+	if err != nil {
+		handleDBError(c, err)
+		return
+	}
+	page := Page{Data: pickAll(items, fields), Total: total, Limit: limit, Offset: offset}
+	if cacheable {
+		h.cache.set(c, gen, page)
+	}
+	setPaginationHeaders(c, total, limit, offset)
+	respondWithETag(c, page)
+}
+
+// listAfter answers ?after= with the rows following that id. Cursor pages
+// are not cached, since each one is only asked for once as a client walks
+// the list.
+func (h *catalogHandlers[T]) listAfter(c *gin.Context, filter CatalogFilter, after uint64) {
+	ctx, cancel := dbContext(c)
+	defer cancel()
+
+	items, more, err := h.repo.ListAfter(ctx, filter, after)
+	if err != nil {
+		handleDBError(c, err)
+		return
+	}
+	page := CursorPage{Data: pickAll(items, filter.Fields), Limit: filter.Limit}
+	if more && len(items) > 0 {
+		next := h.repo.t.id(items[len(items)-1])
+		page.NextCursor = &next
+	}
+	setCursorHeaders(c, page.Limit, page.NextCursor)
+	respondWithETag(c, page)
+}
+
+// stream answers ?stream=true with every matching row as a flat JSON array,
+// skipping pagination, the cache and ETags. Like the CSV exports it runs on
+// the request context rather than dbContext.
+func (h *catalogHandlers[T]) stream(c *gin.Context, filter CatalogFilter) {
+	rows, scan, err := h.repo.Stream(c.Request.Context(), filter)
+	if err != nil {
+		handleDBError(c, err)
+		return
+	}
+	streamJSON(c, rows, scan, filter.Fields)
+}
+
+func (h *catalogHandlers[T]) get(c *gin.Context) {
+	id, ok := parseID(c, h.kind)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := dbContext(c)
+	defer cancel()
+
+	item, err := h.repo.GetByID(ctx, id)
+	if errors.Is(err, ErrNotFound) {
+		h.notFound(c)
+		return
+	}
+	if err != nil {
+		handleDBError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, item)
+}
+
+func (h *catalogHandlers[T]) cheapest(c *gin.Context) {
+	n, err := parseCheapestN(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := dbContext(c)
+	defer cancel()
+
+	items, err := h.repo.Cheapest(ctx, n)
+	if err != nil {
+		handleDBError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, items)
+}
+
+func (h *catalogHandlers[T]) insert(c *gin.Context) {
+	var newItem T
+	if !bindAndValidate(c, &newItem) {
+		return
+	}
+
+	ctx, cancel := dbContext(c)
+	defer cancel()
+
+	item, err := h.repo.Insert(ctx, newItem)
+	if isUniqueViolation(err) {
+		h.conflict(c)
+		return
+	}
+	if err != nil {
+		handleDBError(c, err)
+		return
+	}
+
+	h.cache.invalidate()
+	h.events.publish(changeEvent{Action: "created", Data: item})
+	c.JSON(http.StatusCreated, item)
+}
+
+func (h *catalogHandlers[T]) update(c *gin.Context) {
+	id, ok := parseID(c, h.kind)
+	if !ok {
+		return
+	}
+
+	var updatedItem T
+	if !bindAndValidate(c, &updatedItem) {
+		return
+	}
+	if h.repo.t.version(updatedItem) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"errors": fieldErrors{"version": "version is required"}})
+		return
+	}
+
+	ctx, cancel := dbContext(c)
+	defer cancel()
+
+	item, err := h.repo.Update(ctx, id, updatedItem)
+	if errors.Is(err, ErrNotFound) {
+		h.notFound(c)
+		return
+	}
+	if errors.Is(err, ErrVersionConflict) {
+		c.JSON(http.StatusConflict, gin.H{"error": h.label() + " was changed by someone else; retry against the current version", "current": item})
+		return
+	}
+	if isUniqueViolation(err) {
+		h.conflict(c)
+		return
+	}
+	if err != nil {
+		handleDBError(c, err)
+		return
+	}
+
+	h.cache.invalidate()
+	h.events.publish(changeEvent{Action: "updated", ID: id, Data: item})
+	c.JSON(http.StatusOK, item)
+}
+
+func (h *catalogHandlers[T]) delete(c *gin.Context) {
+	id, ok := parseID(c, h.kind)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := dbContext(c)
+	defer cancel()
+
+	err := h.repo.Delete(ctx, id)
+	if errors.Is(err, ErrNotFound) {
+		h.notFound(c)
+		return
+	}
+	if err != nil {
+		handleDBError(c, err)
+		return
+	}
+	h.cache.invalidate()
+	h.events.publish(changeEvent{Action: "deleted", ID: id})
+	c.JSON(http.StatusOK, gin.H{"message": h.label() + " deleted successfully"})
+}
+
+func (h *catalogHandlers[T]) restore(c *gin.Context) {
+	id, ok := parseID(c, h.kind)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := dbContext(c)
+	defer cancel()
+
+	item, err := h.repo.Restore(ctx, id)
+	if errors.Is(err, ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Deleted " + h.kind + " not found"})
+		return
+	}
+	// The name was reused while this row was deleted.
+	if isUniqueViolation(err) {
+		c.JSON(http.StatusConflict, gin.H{"error": "another " + h.kind + " now has this name; rename or delete it before restoring"})
+		return
+	}
+	if err != nil {
+		handleDBError(c, err)
+		return
+	}
+	h.cache.invalidate()
+	h.events.publish(changeEvent{Action: "restored", ID: id, Data: item})
+	c.JSON(http.StatusOK, item)
+}
@@ -0,0 +1,302 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// catalogTable describes a priced, soft-deletable catalog table. Its columns
+// follow the <kind>_id, <kind>_name, <kind>_price naming used by trains and
+// planes.
+type catalogTable[T any] struct {
+	table   string
+	kind    string
+	columns string
+	scan    func(rowScanner) (T, error)
+	// values returns the writable fields of an item in insert order.
+	values func(T) (name string, price Money, currency string)
+	// version returns the row version an update expects to replace.
+	version func(T) uint
+	// id returns the primary key, which cursor pagination resumes after.
+	id func(T) uint64
+}
+
+type CatalogFilter struct {
+	MinPrice *Money
+	MaxPrice *Money
+	Search   string
+	// IncludeDeleted also returns soft-deleted rows.
+	IncludeDeleted bool
+	// Order is an ORDER BY clause taken from a sort whitelist; it is
+	// concatenated into the query so it must never come from user input.
+	Order  string
+	Limit  int
+	Offset int
+	// Fields names the columns to select, taken from the ?fields= whitelist;
+	// nil selects every column. Fields left out are zero in the results.
+	Fields []string
+}
+
+// catalogSortOrders is the ?sort= whitelist for a catalog table, mapping each
+// value to its ORDER BY clause. Every clause ends in the id so ties come back
+// in a stable order.
+func catalogSortOrders(kind string) map[string]string {
+	id, name, price := kind+"_id", kind+"_name", kind+"_price"
+	return map[string]string{
+		"id_asc":     id + " ASC",
+		"id_desc":    id + " DESC",
+		"price_asc":  price + " ASC, " + id,
+		"price_desc": price + " DESC, " + id,
+		"name_asc":   name + " ASC, " + id,
+		"name_desc":  name + " DESC, " + id,
+	}
+}
+
+// catalogRepository holds the queries shared by every catalog table.
+type catalogRepository[T any] struct {
+	db *timedDB
+	t  catalogTable[T]
+}
+
+func (r *catalogRepository[T]) column(name string) string {
+	return r.t.kind + "_" + name
+}
+
+// selection returns the column list and matching scan function for the
+// filter's Fields.
+func (r *catalogRepository[T]) selection(filter CatalogFilter) (string, func(rowScanner) (T, error)) {
+	if filter.Fields == nil {
+		return r.t.columns, r.t.scan
+	}
+	return strings.Join(filter.Fields, ", "), scanFields[T](filter.Fields)
+}
+
+// where builds the WHERE clause and ORDER BY for a filter, ignoring its
+// paging fields.
+func (r *catalogRepository[T]) where(filter CatalogFilter) (*whereClause, string) {
+	order := filter.Order
+	if order == "" {
+		order = r.column("id")
+	}
+
+	where := &whereClause{}
+	if !filter.IncludeDeleted {
+		where.conditions = append(where.conditions, "deleted_at IS NULL")
+	}
+	if filter.MinPrice != nil {
+		where.add(r.column("price")+" >= $%d", *filter.MinPrice)
+	}
+	if filter.MaxPrice != nil {
+		where.add(r.column("price")+" <= $%d", *filter.MaxPrice)
+	}
+	if filter.Search != "" {
+		where.add(r.column("name")+" ILIKE '%%' || $%d || '%%'", escapeLike(filter.Search))
+	}
+	return where, order
+}
+
+// List returns one page of rows matching the filter along with the total
+// number of matching rows.
+func (r *catalogRepository[T]) List(ctx context.Context, filter CatalogFilter) (items []T, total int, err error) {
+	err = retryRead(ctx, func() error {
+		var err error
+		items, total, err = r.list(ctx, filter)
+		return err
+	})
+	return items, total, err
+}
+
+func (r *catalogRepository[T]) list(ctx context.Context, filter CatalogFilter) ([]T, int, error) {
+	where, order := r.where(filter)
+
+	var total int
+	if err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM "+r.t.table+where.String(), where.args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	columns, scan := r.selection(filter)
+	query := fmt.Sprintf("SELECT %s FROM %s%s ORDER BY %s LIMIT $%d OFFSET $%d", columns, r.t.table, where, order, len(where.args)+1, len(where.args)+2)
+	rows, err := r.db.QueryContext(ctx, query, append(where.args, filter.Limit, filter.Offset)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	items, err := scanAll(rows, scan)
+	return items, total, err
+}
+
+// ListAfter returns up to filter.Limit rows matching the filter whose id is
+// greater than after, in id order, ignoring filter.Order and filter.Offset.
+// more reports whether further rows follow.
+func (r *catalogRepository[T]) ListAfter(ctx context.Context, filter CatalogFilter, after uint64) (items []T, more bool, err error) {
+	where, _ := r.where(filter)
+	where.add(r.column("id")+" > $%d", after)
+	columns, scan := r.selection(filter)
+	query := fmt.Sprintf("SELECT %s FROM %s%s ORDER BY %s LIMIT $%d", columns, r.t.table, where, r.column("id"), len(where.args)+1)
+
+	err = retryRead(ctx, func() error {
+		rows, err := r.db.QueryContext(ctx, query, append(where.args, filter.Limit+1)...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		items, err = scanAll(rows, scan)
+		return err
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	if len(items) > filter.Limit {
+		return items[:filter.Limit], true, nil
+	}
+	return items, false, nil
+}
+
+// Cheapest returns the n lowest-priced live rows, cheapest first.
+func (r *catalogRepository[T]) Cheapest(ctx context.Context, n int) ([]T, error) {
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE deleted_at IS NULL ORDER BY %s LIMIT $1", r.t.columns, r.t.table, r.column("price")+" ASC, "+r.column("id"))
+	return retryReadValue(ctx, func() ([]T, error) {
+		rows, err := r.db.QueryContext(ctx, query, n)
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+		return scanAll(rows, r.t.scan)
+	})
+}
+
+func (r *catalogRepository[T]) Count(ctx context.Context) (int, error) {
+	return retryReadValue(ctx, func() (int, error) {
+		var count int
+		err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM "+r.t.table+" WHERE deleted_at IS NULL").Scan(&count)
+		return count, err
+	})
+}
+
+func (r *catalogRepository[T]) Stats(ctx context.Context) (PriceStats, error) {
+	price := r.column("price")
+	query := fmt.Sprintf("SELECT COUNT(*), MIN(%[1]s), MAX(%[1]s), AVG(%[1]s), COALESCE(SUM(%[1]s), 0) FROM %[2]s WHERE deleted_at IS NULL", price, r.t.table)
+	return retryReadValue(ctx, func() (PriceStats, error) {
+		return scanPriceStats(r.db.QueryRowContext(ctx, query))
+	})
+}
+
+// Prices returns the distinct prices in use, lowest first.
+func (r *catalogRepository[T]) Prices(ctx context.Context) ([]Money, error) {
+	price := r.column("price")
+	query := fmt.Sprintf("SELECT DISTINCT %[1]s FROM %[2]s WHERE deleted_at IS NULL ORDER BY %[1]s", price, r.t.table)
+	return retryReadValue(ctx, func() ([]Money, error) {
+		return queryPrices(ctx, r.db, query)
+	})
+}
+
+func (r *catalogRepository[T]) GetByID(ctx context.Context, id uint64) (T, error) {
+	return retryReadValue(ctx, func() (T, error) {
+		return r.one(r.db.QueryRowContext(ctx, "SELECT "+r.t.columns+" FROM "+r.t.table+" WHERE "+r.column("id")+"=$1 AND deleted_at IS NULL", id))
+	})
+}
+
+// GetByName looks up a live row by its exact name.
+func (r *catalogRepository[T]) GetByName(ctx context.Context, name string) (T, error) {
+	return retryReadValue(ctx, func() (T, error) {
+		return r.one(r.db.QueryRowContext(ctx, "SELECT "+r.t.columns+" FROM "+r.t.table+" WHERE "+r.column("name")+"=$1 AND deleted_at IS NULL", name))
+	})
+}
+
+func (r *catalogRepository[T]) Insert(ctx context.Context, item T) (T, error) {
+	name, price, currency := r.t.values(item)
+	query := fmt.Sprintf("INSERT INTO %s (%s, %s, currency) VALUES ($1, $2, $3) RETURNING %s", r.t.table, r.column("name"), r.column("price"), r.t.columns)
+	return r.t.scan(r.db.QueryRowContext(ctx, query, name, price, currencyOrBase(currency)))
+}
+
+// Update replaces a row only if it is still at the version carried by item.
+// When someone else has updated it first it returns ErrVersionConflict along
+// with the current row.
+func (r *catalogRepository[T]) Update(ctx context.Context, id uint64, item T) (T, error) {
+	name, price, currency := r.t.values(item)
+	query := fmt.Sprintf("UPDATE %s SET %s=$1, %s=$2, currency=$3, version=version+1 WHERE %s=$4 AND version=$5 AND deleted_at IS NULL RETURNING %s", r.t.table, r.column("name"), r.column("price"), r.column("id"), r.t.columns)
+	updated, err := r.one(r.db.QueryRowContext(ctx, query, name, price, currencyOrBase(currency), id, r.t.version(item)))
+	if !errors.Is(err, ErrNotFound) {
+		return updated, err
+	}
+
+	current, err := r.GetByID(ctx, id)
+	if err != nil {
+		return current, err
+	}
+	return current, ErrVersionConflict
+}
+
+// Restore clears deleted_at on a soft-deleted row. It returns ErrNotFound
+// when the row does not exist or is not deleted.
+func (r *catalogRepository[T]) Restore(ctx context.Context, id uint64) (T, error) {
+	return r.one(r.db.QueryRowContext(ctx, "UPDATE "+r.t.table+" SET deleted_at = NULL WHERE "+r.column("id")+"=$1 AND deleted_at IS NOT NULL RETURNING "+r.t.columns, id))
+}
+
+// Delete soft-deletes the row by stamping deleted_at; the row is kept so
+// history that refers to it stays meaningful.
+func (r *catalogRepository[T]) Delete(ctx context.Context, id uint64) error {
+	result, err := r.db.ExecContext(ctx, "UPDATE "+r.t.table+" SET deleted_at = now() WHERE "+r.column("id")+" = $1 AND deleted_at IS NULL", id)
+	if err != nil {
+		return err
+	}
+	return checkRowsAffected(result)
+}
+
+// DeleteBatch soft-deletes every live row in ids and returns the ids that
+// were actually deleted.
+func (r *catalogRepository[T]) DeleteBatch(ctx context.Context, ids []int64) ([]int64, error) {
+	id := r.column("id")
+	rows, err := r.db.QueryContext(ctx, "UPDATE "+r.t.table+" SET deleted_at = now() WHERE "+id+" = ANY($1) AND deleted_at IS NULL RETURNING "+id, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	return scanIDs(rows)
+}
+
+// Stream returns every row matching the filter, ignoring Limit and Offset,
+// for callers that write results out as they are scanned, along with the
+// scan function matching the selected columns. The caller must close the
+// rows.
+func (r *catalogRepository[T]) Stream(ctx context.Context, filter CatalogFilter) (*sql.Rows, func(rowScanner) (T, error), error) {
+	where, order := r.where(filter)
+	columns, scan := r.selection(filter)
+	rows, err := r.db.QueryContext(ctx, fmt.Sprintf("SELECT %s FROM %s%s ORDER BY %s", columns, r.t.table, where, order), where.args...)
+	return rows, scan, err
+}
+
+// Cursor returns every live row in id order for callers that stream results
+// instead of loading them into memory. The caller must close the rows.
+func (r *catalogRepository[T]) Cursor(ctx context.Context) (*sql.Rows, error) {
+	return r.db.QueryContext(ctx, "SELECT "+r.t.columns+" FROM "+r.t.table+" WHERE deleted_at IS NULL ORDER BY "+r.column("id"))
+}
+
+// scanAll reads every remaining row; an empty result is an empty slice so it
+// encodes as [].
+func scanAll[T any](rows *sql.Rows, scan func(rowScanner) (T, error)) ([]T, error) {
+	items := []T{}
+	for rows.Next() {
+		item, err := scan(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// one scans a single-row result, mapping no rows to ErrNotFound.
+func (r *catalogRepository[T]) one(row *sql.Row) (T, error) {
+	item, err := r.t.scan(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		var zero T
+		return zero, ErrNotFound
+	}
+	return item, err
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary	Cheapest trains
+// @Tags	trains
+// @Produce	json
+// @Param	n	query	int	false	"How many to return (default 5, max 50)"
+// @Success	200	{array}	Train
+// @Failure	400	{object}	errorResponse
+// @Failure	500	{object}	errorResponse
+// @Router	/trains/cheapest [get]
+func cheapestTrains(c *gin.Context) {
+	trainHandlers.cheapest(c)
+}
+
+// @Summary	Cheapest planes
+// @Tags	planes
+// @Produce	json
+// @Param	n	query	int	false	"How many to return (default 5, max 50)"
+// @Success	200	{array}	Plane
+// @Failure	400	{object}	errorResponse
+// @Failure	500	{object}	errorResponse
+// @Router	/planes/cheapest [get]
+func cheapestPlanes(c *gin.Context) {
+	planeHandlers.cheapest(c)
+}
+
+// cheapestOverall merges the n cheapest trains and the n cheapest planes and
+// keeps the n cheapest of those. Like /search it compares prices as stored,
+// whatever their currency.
+//
+// @Summary	Cheapest trains and planes
+// @Tags	search
+// @Produce	json
+// @Param	n	query	int	false	"How many to return (default 5, max 50)"
+// @Success	200	{array}	SearchResult
+// @Failure	400	{object}	errorResponse
+// @Failure	500	{object}	errorResponse
+// @Router	/cheapest [get]
+func cheapestOverall(c *gin.Context) {
+	n, err := parseCheapestN(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := dbContext(c)
+	defer cancel()
+
+	var (
+		wg                 sync.WaitGroup
+		trains             []Train
+		planes             []Plane
+		trainErr, planeErr error
+	)
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		trains, trainErr = trainRepo.Cheapest(ctx, n)
+	}()
+	go func() {
+		defer wg.Done()
+		planes, planeErr = planeRepo.Cheapest(ctx, n)
+	}()
+	wg.Wait()
+
+	if trainErr != nil {
+		handleDBError(c, trainErr)
+		return
+	}
+	if planeErr != nil {
+		handleDBError(c, planeErr)
+		return
+	}
+
+	results := make([]SearchResult, 0, len(trains)+len(planes))
+	for _, train := range trains {
+		results = append(results, SearchResult{Type: "train", ID: train.ID, Name: train.Name, Price: train.Price, Currency: train.Currency, CreatedAt: train.CreatedAt})
+	}
+	for _, plane := range planes {
+		results = append(results, SearchResult{Type: "plane", ID: plane.ID, Name: plane.Name, Price: plane.Price, Currency: plane.Currency, CreatedAt: plane.CreatedAt})
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Price < results[j].Price
+	})
+	if len(results) > n {
+		results = results[:n]
+	}
+
+	c.JSON(http.StatusOK, results)
+}
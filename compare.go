@@ -0,0 +1,103 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Comparison sets a train against a plane with the same name. Cheaper and
+// Difference are only filled in when both exist and share a currency.
+type Comparison struct {
+	Name  string `json:"name"`
+	Train *Train `json:"train"`
+	Plane *Plane `json:"plane"`
+	// Partial is true when only one of the two exists.
+	Partial          bool   `json:"partial"`
+	CurrencyMismatch bool   `json:"currency_mismatch,omitempty"`
+	Cheaper          string `json:"cheaper,omitempty"`
+	Difference       *Money `json:"difference,omitempty" swaggertype:"number"`
+}
+
+// comparePrices looks up the train and plane named ?name= concurrently and
+// reports which is cheaper.
+//
+// @Summary	Compare train and plane prices for a route
+// @Tags	search
+// @Produce	json
+// @Param	name	query	string	true	"Exact route name"
+// @Success	200	{object}	Comparison
+// @Failure	400	{object}	errorResponse
+// @Failure	404	{object}	errorResponse
+// @Failure	500	{object}	errorResponse
+// @Router	/compare [get]
+func comparePrices(c *gin.Context) {
+	name := strings.TrimSpace(c.Query("name"))
+	if name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name is required"})
+		return
+	}
+
+	ctx, cancel := dbContext(c)
+	defer cancel()
+
+	var (
+		wg                 sync.WaitGroup
+		train              Train
+		plane              Plane
+		trainErr, planeErr error
+	)
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		train, trainErr = trainRepo.GetByName(ctx, name)
+	}()
+	go func() {
+		defer wg.Done()
+		plane, planeErr = planeRepo.GetByName(ctx, name)
+	}()
+	wg.Wait()
+
+	for _, err := range []error{trainErr, planeErr} {
+		if err != nil && !errors.Is(err, ErrNotFound) {
+			handleDBError(c, err)
+			return
+		}
+	}
+
+	result := Comparison{Name: name}
+	if trainErr == nil {
+		result.Train = &train
+	}
+	if planeErr == nil {
+		result.Plane = &plane
+	}
+
+	switch {
+	case result.Train == nil && result.Plane == nil:
+		c.JSON(http.StatusNotFound, gin.H{"error": "No train or plane with that name"})
+		return
+	case result.Train == nil || result.Plane == nil:
+		result.Partial = true
+	case train.Currency != plane.Currency:
+		result.CurrencyMismatch = true
+	default:
+		var diff Money
+		switch {
+		case train.Price < plane.Price:
+			result.Cheaper = "train"
+			diff = plane.Price - train.Price
+		case plane.Price < train.Price:
+			result.Cheaper = "plane"
+			diff = train.Price - plane.Price
+		default:
+			result.Cheaper = "same"
+		}
+		result.Difference = &diff
+	}
+
+	c.JSON(http.StatusOK, result)
+}
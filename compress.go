@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// gzipWriter buffers the start of a response and only switches to gzip once
+// it grows past minSize, so small bodies are sent as-is.
+type gzipWriter struct {
+	gin.ResponseWriter
+	level       int
+	minSize     int
+	buf         bytes.Buffer
+	gz          *gzip.Writer
+	passthrough bool
+}
+
+func (w *gzipWriter) Write(b []byte) (int, error) {
+	if w.passthrough {
+		return w.ResponseWriter.Write(b)
+	}
+	if w.gz != nil {
+		return w.gz.Write(b)
+	}
+
+	w.buf.Write(b)
+	if w.buf.Len() < w.minSize {
+		return len(b), nil
+	}
+	if err := w.startGzip(); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+func (w *gzipWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// Written counts the buffered start of the body, so middleware such as
+// requestTimeout doesn't write a second response after a small one that is
+// still held back.
+func (w *gzipWriter) Written() bool {
+	return w.buf.Len() > 0 || w.ResponseWriter.Written()
+}
+
+// Size is what has reached the client, compressed if gzip has started,
+// plus what is still buffered, so the access log reports the bytes sent.
+func (w *gzipWriter) Size() int {
+	if w.buf.Len() == 0 {
+		return w.ResponseWriter.Size()
+	}
+	return max(w.ResponseWriter.Size(), 0) + w.buf.Len()
+}
+
+func (w *gzipWriter) startGzip() error {
+	header := w.Header()
+	if header.Get("Content-Encoding") != "" {
+		return w.startPassthrough()
+	}
+	header.Set("Content-Encoding", "gzip")
+	header.Add("Vary", "Accept-Encoding")
+	header.Del("Content-Length")
+
+	gz, err := gzip.NewWriterLevel(w.ResponseWriter, w.level)
+	if err != nil {
+		return err
+	}
+	w.gz = gz
+	_, err = w.gz.Write(w.buf.Bytes())
+	w.buf.Reset()
+	return err
+}
+
+func (w *gzipWriter) startPassthrough() error {
+	w.passthrough = true
+	_, err := w.ResponseWriter.Write(w.buf.Bytes())
+	w.buf.Reset()
+	return err
+}
+
+// Flush sends whatever is buffered. A handler that flushes before reaching
+// minSize is streaming, so the rest of its response is left uncompressed.
+func (w *gzipWriter) Flush() {
+	if w.gz != nil {
+		w.gz.Flush()
+	} else if !w.passthrough {
+		w.startPassthrough()
+	}
+	w.ResponseWriter.Flush()
+}
+
+func (w *gzipWriter) close() error {
+	if w.gz != nil {
+		return w.gz.Close()
+	}
+	if !w.passthrough && w.buf.Len() > 0 {
+		return w.startPassthrough()
+	}
+	return nil
+}
+
+// gzipMiddleware compresses responses larger than minSize for clients that
+// accept gzip. Routes ending in one of skipSuffixes are never compressed,
+// which keeps streamed exports and the metrics handler (which negotiates its
+// own encoding) untouched.
+func gzipMiddleware(level int, minSize int, skipSuffixes ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method == http.MethodHead || !strings.Contains(c.GetHeader("Accept-Encoding"), "gzip") {
+			c.Next()
+			return
+		}
+		route := c.FullPath()
+		for _, suffix := range skipSuffixes {
+			if strings.HasSuffix(route, suffix) {
+				c.Next()
+				return
+			}
+		}
+
+		writer := &gzipWriter{ResponseWriter: c.Writer, level: level, minSize: minSize}
+		c.Writer = writer
+		defer func() {
+			if err := writer.close(); err != nil {
+				log.Printf("gzip: failed to finish response: %v", err)
+			}
+		}()
+		c.Next()
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+const testGzipMinSize = 64
+
+// serveGzip runs handler behind gzipMiddleware for a client that accepts
+// gzip, recording the writer's Written and Size as the outer middleware
+// sees them once the chain has finished.
+func serveGzip(t *testing.T, handler gin.HandlerFunc) (*httptest.ResponseRecorder, bool, int) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	var written bool
+	var size int
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Next()
+		written, size = c.Writer.Written(), c.Writer.Size()
+	})
+	router.Use(gzipMiddleware(gzip.DefaultCompression, testGzipMinSize))
+	router.GET("/", handler)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w, written, size
+}
+
+func TestGzipSmallBodyPassesThrough(t *testing.T) {
+	body := `{"ok":true}`
+	var writtenInside bool
+	var sizeInside int
+	w, written, size := serveGzip(t, func(c *gin.Context) {
+		c.Data(http.StatusOK, "application/json", []byte(body))
+		writtenInside, sizeInside = c.Writer.Written(), c.Writer.Size()
+	})
+
+	if got := w.Header().Get("Content-Encoding"); got != "" {
+		t.Errorf("Content-Encoding = %q, want none", got)
+	}
+	if w.Body.String() != body {
+		t.Errorf("body = %q, want %q", w.Body.String(), body)
+	}
+	if !writtenInside || sizeInside != len(body) {
+		t.Errorf("while buffered: Written = %v, Size = %d; want true, %d", writtenInside, sizeInside, len(body))
+	}
+	if !written || size != len(body) {
+		t.Errorf("after the response: Written = %v, Size = %d; want true, %d", written, size, len(body))
+	}
+}
+
+func TestGzipLargeBodyIsCompressed(t *testing.T) {
+	body := strings.Repeat("train ", 100)
+	w, written, size := serveGzip(t, func(c *gin.Context) {
+		c.String(http.StatusOK, body)
+	})
+
+	if got := w.Header().Get("Content-Encoding"); got != "gzip" {
+		t.Fatalf("Content-Encoding = %q, want gzip", got)
+	}
+	if got := w.Header().Get("Vary"); got != "Accept-Encoding" {
+		t.Errorf("Vary = %q, want Accept-Encoding", got)
+	}
+	if !written || size != w.Body.Len() {
+		t.Errorf("Written = %v, Size = %d; want true, %d compressed bytes", written, size, w.Body.Len())
+	}
+
+	gz, err := gzip.NewReader(w.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := io.ReadAll(gz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(decoded) != body {
+		t.Errorf("decoded body = %q, want %q", decoded, body)
+	}
+}
+
+func TestGzipFlushPassesThrough(t *testing.T) {
+	first, rest := "[", strings.Repeat("x", 2*testGzipMinSize)+"]"
+	w, _, size := serveGzip(t, func(c *gin.Context) {
+		c.Status(http.StatusOK)
+		c.Writer.WriteString(first)
+		c.Writer.Flush()
+		c.Writer.WriteString(rest)
+	})
+
+	if got := w.Header().Get("Content-Encoding"); got != "" {
+		t.Errorf("Content-Encoding = %q, want none after an early flush", got)
+	}
+	if !w.Flushed {
+		t.Error("Flush did not reach the underlying writer")
+	}
+	if want := first + rest; w.Body.String() != want {
+		t.Errorf("body = %q, want %q", w.Body.String(), want)
+	}
+	if size != len(first)+len(rest) {
+		t.Errorf("Size = %d, want %d", size, len(first)+len(rest))
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// requiredEnv lists the variables the service cannot start without.
+var requiredEnv = []string{
+	"DATABASE_USERNAME",
+	"DATABASE_PASSWORD",
+	"DATABASE_HOST",
+	"DATABASE_PORT",
+	"DATABASE_NAME",
+	"PORT",
+}
+
+// checkRequiredEnv exits with a single message naming every required
+// variable that is unset or empty.
+func checkRequiredEnv() {
+	var missing []string
+	for _, name := range requiredEnv {
+		if strings.TrimSpace(os.Getenv(name)) == "" {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		log.Fatalf("Missing required environment variables: %s", strings.Join(missing, ", "))
+	}
+}
+
+func envInt(name string, fallback int) int {
+	v := os.Getenv(name)
+	if v == "" {
+		return fallback
+	}
+	parsed, err := strconv.Atoi(v)
+	if err != nil || parsed < 0 {
+		log.Fatalf("Invalid %s %q: must be a non-negative integer", name, v)
+	}
+	return parsed
+}
+
+func envDuration(name string, fallback time.Duration) time.Duration {
+	v := os.Getenv(name)
+	if v == "" {
+		return fallback
+	}
+	parsed, err := time.ParseDuration(v)
+	if err != nil || parsed < 0 {
+		log.Fatalf("Invalid %s %q: must be a non-negative duration such as 30s or 5m", name, v)
+	}
+	return parsed
+}
+
+// envList splits a comma-separated variable, dropping blank entries.
+func envList(name string) []string {
+	var values []string
+	for _, v := range strings.Split(os.Getenv(name), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+func envFloat(name string, fallback float64) float64 {
+	v := os.Getenv(name)
+	if v == "" {
+		return fallback
+	}
+	parsed, err := strconv.ParseFloat(v, 64)
+	if err != nil || parsed < 0 {
+		log.Fatalf("Invalid %s %q: must be a non-negative number", name, v)
+	}
+	return parsed
+}
+
+func envBool(name string, fallback bool) bool {
+	v := os.Getenv(name)
+	if v == "" {
+		return fallback
+	}
+	parsed, err := strconv.ParseBool(v)
+	if err != nil {
+		log.Fatalf("Invalid %s %q: must be true or false", name, v)
+	}
+	return parsed
+}
+
+// envMoney reads an amount such as 19.99.
+func envMoney(name string, fallback Money) Money {
+	v := os.Getenv(name)
+	if v == "" {
+		return fallback
+	}
+	parsed, err := ParseMoney(v)
+	if err != nil {
+		log.Fatalf("Invalid %s %q: must be an amount such as 1 or 0.50", name, v)
+	}
+	return parsed
+}
+
+// envSort reads a default sort such as TRAINS_DEFAULT_SORT and returns its
+// ORDER BY clause, exiting on a value orders does not list.
+func envSort(name string, orders map[string]string, fallback string) string {
+	v := os.Getenv(name)
+	if v == "" {
+		v = fallback
+	}
+	order, ok := orders[v]
+	if !ok {
+		keys := make([]string, 0, len(orders))
+		for key := range orders {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		log.Fatalf("Invalid %s %q: must be one of %s", name, v, strings.Join(keys, ", "))
+	}
+	return order
+}
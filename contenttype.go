@@ -0,0 +1,33 @@
+package main
+
+import (
+	"mime"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// requireJSON answers 415 to POST, PUT and PATCH requests that send anything
+// but application/json. Requests with neither a body nor a Content-Type,
+// such as booking or restoring, have nothing to check and pass through.
+func requireJSON() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch:
+		default:
+			c.Next()
+			return
+		}
+
+		header := c.GetHeader("Content-Type")
+		if header == "" && c.Request.ContentLength == 0 {
+			c.Next()
+			return
+		}
+		if mediaType, _, err := mime.ParseMediaType(header); err != nil || mediaType != "application/json" {
+			c.AbortWithStatusJSON(http.StatusUnsupportedMediaType, gin.H{"error": "Content-Type must be application/json"})
+			return
+		}
+		c.Next()
+	}
+}
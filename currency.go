@@ -0,0 +1,36 @@
+package main
+
+import (
+	"sort"
+	"strings"
+)
+
+// supportedCurrencies is the ISO 4217 whitelist for train and plane prices.
+// SUPPORTED_CURRENCIES replaces it.
+var supportedCurrencies = map[string]bool{
+	"USD": true,
+	"EUR": true,
+	"GBP": true,
+	"RUB": true,
+	"KZT": true,
+	"UZS": true,
+}
+
+// baseCurrency is used when a train or plane is written without a currency.
+var baseCurrency = "USD"
+
+func currencyOrBase(code string) string {
+	if code == "" {
+		return baseCurrency
+	}
+	return code
+}
+
+func supportedCurrencyList() string {
+	codes := make([]string, 0, len(supportedCurrencies))
+	for code := range supportedCurrencies {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return strings.Join(codes, ", ")
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+const dashboardRecentHistory = 5
+
+// Dashboard is the admin overview. A section that failed to load is left
+// null and named in Errors.
+type Dashboard struct {
+	Trains        *int              `json:"trains"`
+	Planes        *int              `json:"planes"`
+	History       *int              `json:"history"`
+	RecentHistory []History         `json:"recent_history"`
+	Errors        map[string]string `json:"errors,omitempty"`
+}
+
+// dashboard gathers the overview sections concurrently and returns whatever
+// succeeded rather than failing the whole response on one bad query.
+//
+// @Summary	Admin overview
+// @Tags	dashboard
+// @Produce	json
+// @Success	200	{object}	Dashboard
+// @Router	/dashboard [get]
+func dashboard(c *gin.Context) {
+	ctx, cancel := dbContext(c)
+	defer cancel()
+
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		result Dashboard
+	)
+	fail := func(section string, err error) {
+		slog.Error("dashboard section failed", "request_id", c.GetString(requestIDKey), "section", section, "error", err)
+		_, message := classifyDBError(err)
+		if isTimeout(err) {
+			message = "Database query timed out"
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if result.Errors == nil {
+			result.Errors = map[string]string{}
+		}
+		result.Errors[section] = message
+	}
+	count := func(section string, target **int, count func(context.Context) (int, error)) {
+		defer wg.Done()
+		n, err := count(ctx)
+		if err != nil {
+			fail(section, err)
+			return
+		}
+		*target = &n
+	}
+
+	wg.Add(4)
+	go count("trains", &result.Trains, trainRepo.Count)
+	go count("planes", &result.Planes, planeRepo.Count)
+	go count("history", &result.History, historyRepo.Count)
+	go func() {
+		defer wg.Done()
+		recent, _, err := historyRepo.List(ctx, HistoryFilter{Limit: dashboardRecentHistory})
+		if err != nil {
+			fail("recent_history", err)
+			return
+		}
+		result.RecentHistory = recent
+	}()
+	wg.Wait()
+
+	c.JSON(http.StatusOK, result)
+}
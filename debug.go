@@ -0,0 +1,89 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// IndexInfo is one row of pg_indexes.
+type IndexInfo struct {
+	Table      string `json:"table"`
+	Name       string `json:"name"`
+	Definition string `json:"definition"`
+}
+
+// @Summary	List database indexes
+// @Description	Reports the indexes in the application's schema, to check that migrations such as the price indexes have been applied.
+// @Tags	debug
+// @Produce	json
+// @Security	BearerAuth
+// @Success	200	{array}	IndexInfo
+// @Failure	401	{object}	errorResponse
+// @Failure	403	{object}	errorResponse
+// @Failure	500	{object}	errorResponse
+// @Router	/debug/indexes [get]
+func debugIndexes(c *gin.Context) {
+	ctx, cancel := dbContext(c)
+	defer cancel()
+
+	rows, err := db.QueryContext(ctx, "SELECT tablename, indexname, indexdef FROM pg_indexes WHERE schemaname = current_schema() ORDER BY tablename, indexname")
+	if err != nil {
+		handleDBError(c, err)
+		return
+	}
+	defer rows.Close()
+
+	indexes := []IndexInfo{}
+	for rows.Next() {
+		var index IndexInfo
+		if err := rows.Scan(&index.Table, &index.Name, &index.Definition); err != nil {
+			handleDBError(c, err)
+			return
+		}
+		indexes = append(indexes, index)
+	}
+	if err := rows.Err(); err != nil {
+		handleDBError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, indexes)
+}
+
+// DBStats is the connection pool's sql.DBStats, with durations in
+// milliseconds.
+type DBStats struct {
+	MaxOpenConnections int   `json:"max_open_connections"`
+	OpenConnections    int   `json:"open_connections"`
+	InUse              int   `json:"in_use"`
+	Idle               int   `json:"idle"`
+	WaitCount          int64 `json:"wait_count"`
+	WaitDurationMs     int64 `json:"wait_duration_ms"`
+	MaxIdleClosed      int64 `json:"max_idle_closed"`
+	MaxIdleTimeClosed  int64 `json:"max_idle_time_closed"`
+	MaxLifetimeClosed  int64 `json:"max_lifetime_closed"`
+}
+
+// @Summary	Show connection pool statistics
+// @Description	Reports the database pool's counters. A wait count that keeps climbing while in_use sits at max_open_connections means the pool is exhausted.
+// @Tags	debug
+// @Produce	json
+// @Security	BearerAuth
+// @Success	200	{object}	DBStats
+// @Failure	401	{object}	errorResponse
+// @Failure	403	{object}	errorResponse
+// @Router	/debug/dbstats [get]
+func debugDBStats(c *gin.Context) {
+	stats := db.Stats()
+	c.JSON(http.StatusOK, DBStats{
+		MaxOpenConnections: stats.MaxOpenConnections,
+		OpenConnections:    stats.OpenConnections,
+		InUse:              stats.InUse,
+		Idle:               stats.Idle,
+		WaitCount:          stats.WaitCount,
+		WaitDurationMs:     stats.WaitDuration.Milliseconds(),
+		MaxIdleClosed:      stats.MaxIdleClosed,
+		MaxIdleTimeClosed:  stats.MaxIdleTimeClosed,
+		MaxLifetimeClosed:  stats.MaxLifetimeClosed,
+	})
+}
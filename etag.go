@@ -0,0 +1,63 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// respondWithETag serialises obj, tags it with a hash of the body and answers
+// 304 Not Modified when the client already holds that version. Because the
+// tag is derived from the body it changes whenever the data does. The body is
+// XML instead of JSON when the Accept header asks for it.
+func respondWithETag(c *gin.Context, obj interface{}) {
+	// Shared caches must keep the JSON and XML bodies apart.
+	c.Writer.Header().Add("Vary", "Accept")
+	contentType := "application/json; charset=utf-8"
+	marshal := json.Marshal
+	if wantsXML(c) {
+		contentType = "application/xml; charset=utf-8"
+		marshal = xml.Marshal
+	}
+	body, err := marshal(obj)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	c.Header("ETag", etag)
+
+	if etagMatches(c.GetHeader("If-None-Match"), etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.Data(http.StatusOK, contentType, body)
+}
+
+func etagMatches(ifNoneMatch string, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag || candidate == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// wantsXML reports whether the client prefers XML over JSON. JSON stays the
+// default when Accept is missing or lists JSON first.
+func wantsXML(c *gin.Context) bool {
+	switch c.NegotiateFormat(binding.MIMEJSON, binding.MIMEXML, binding.MIMEXML2) {
+	case binding.MIMEXML, binding.MIMEXML2:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// eventHeartbeat is how often an idle event stream sends a comment line, so
+// proxies don't close it and dead clients are noticed.
+const eventHeartbeat = 15 * time.Second
+
+// eventBuffer is how many events a subscriber may fall behind before new
+// ones are dropped for it.
+const eventBuffer = 16
+
+// changeEvent describes one write to a catalog table. Data is the row as it
+// now stands, or absent for deletes. ID is the id of the row a write was
+// addressed to, so creates carry it only in Data. Bulk writes carry neither
+// and only tell clients to refetch.
+type changeEvent struct {
+	Action string      `json:"action" example:"updated"`
+	ID     uint64      `json:"id,omitempty"`
+	Data   interface{} `json:"data,omitempty"`
+}
+
+// broadcaster fans change events out to every connected event stream.
+// Publishing never blocks a write handler: a subscriber whose buffer is full
+// misses the event.
+type broadcaster struct {
+	mu          sync.Mutex
+	subscribers map[chan changeEvent]struct{}
+	closed      bool
+}
+
+func newBroadcaster() *broadcaster {
+	return &broadcaster{subscribers: map[chan changeEvent]struct{}{}}
+}
+
+var trainEvents = newBroadcaster()
+
+// subscribe returns a channel of events and a function that must be called
+// to stop receiving them. The channel is closed by unsubscribe or close.
+func (b *broadcaster) subscribe() (<-chan changeEvent, func()) {
+	ch := make(chan changeEvent, eventBuffer)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		close(ch)
+		return ch, func() {}
+	}
+	b.subscribers[ch] = struct{}{}
+
+	return ch, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if _, ok := b.subscribers[ch]; ok {
+			delete(b.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// publish is safe to call on a nil broadcaster, which drops the event.
+func (b *broadcaster) publish(event changeEvent) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ch := range b.subscribers {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+// close ends every stream, so shutdown is not held up by connected clients.
+func (b *broadcaster) close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	for ch := range b.subscribers {
+		delete(b.subscribers, ch)
+		close(ch)
+	}
+}
+
+// @Summary	Stream train changes
+// @Description	Server-Sent Events: each "change" event carries a JSON changeEvent with action created, updated, deleted, restored or bulk.
+// @Tags	trains
+// @Produce	text/event-stream
+// @Success	200	{object}	changeEvent
+// @Router	/trains/events [get]
+func trainEventStream(c *gin.Context) {
+	streamEvents(c, trainEvents)
+}
+
+// streamEvents holds the connection open and writes each event from b until
+// the client goes away or the server shuts down. It watches the connection
+// rather than the request deadline, which would otherwise cut every stream
+// off after the request timeout.
+func streamEvents(c *gin.Context, b *broadcaster) {
+	events, unsubscribe := b.subscribe()
+	defer unsubscribe()
+
+	done := c.Request.Context().Done()
+	if base, ok := c.Get(baseContextKey); ok {
+		done = base.(context.Context).Done()
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(eventHeartbeat)
+	defer heartbeat.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-done:
+			return false
+		case event, ok := <-events:
+			if !ok {
+				return false
+			}
+			c.SSEvent("change", event)
+		case <-heartbeat.C:
+			if _, err := io.WriteString(w, ": keep-alive\n\n"); err != nil {
+				return false
+			}
+		}
+		return true
+	})
+}
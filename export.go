@@ -0,0 +1,120 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// csvFlushEvery controls how many records are buffered before they are
+// pushed to the client.
+const csvFlushEvery = 100
+
+// @Summary	Export trains as CSV
+// @Tags	trains
+// @Produce	text/csv
+// @Success	200	{file}	file
+// @Failure	500	{object}	errorResponse
+// @Router	/trains/export [get]
+func exportTrains(c *gin.Context) {
+	rows, err := trainRepo.Cursor(c.Request.Context())
+	if err != nil {
+		handleDBError(c, err)
+		return
+	}
+	streamCSV(c, "trains.csv", []string{"train_id", "train_name", "train_price", "currency", "created_at"}, rows, func(rows *sql.Rows) ([]string, error) {
+		train, err := scanTrain(rows)
+		return []string{formatUint(train.ID), train.Name, train.Price.String(), train.Currency, train.CreatedAt.Format(time.RFC3339)}, err
+	})
+}
+
+// @Summary	Export planes as CSV
+// @Tags	planes
+// @Produce	text/csv
+// @Success	200	{file}	file
+// @Failure	500	{object}	errorResponse
+// @Router	/planes/export [get]
+func exportPlanes(c *gin.Context) {
+	rows, err := planeRepo.Cursor(c.Request.Context())
+	if err != nil {
+		handleDBError(c, err)
+		return
+	}
+	streamCSV(c, "planes.csv", []string{"plane_id", "plane_name", "plane_price", "currency", "created_at"}, rows, func(rows *sql.Rows) ([]string, error) {
+		plane, err := scanPlane(rows)
+		return []string{formatUint(plane.ID), plane.Name, plane.Price.String(), plane.Currency, plane.CreatedAt.Format(time.RFC3339)}, err
+	})
+}
+
+// @Summary	Export history as CSV
+// @Tags	history
+// @Produce	text/csv
+// @Success	200	{file}	file
+// @Failure	500	{object}	errorResponse
+// @Router	/history/export [get]
+func exportHistory(c *gin.Context) {
+	rows, err := historyRepo.Cursor(c.Request.Context())
+	if err != nil {
+		handleDBError(c, err)
+		return
+	}
+	streamCSV(c, "history.csv", []string{"history_id", "history_name", "history_price", "created_at"}, rows, func(rows *sql.Rows) ([]string, error) {
+		history, err := scanHistory(rows)
+		return []string{formatUint(history.ID), history.Name, history.Price.String(), history.CreatedAt.Format(time.RFC3339)}, err
+	})
+}
+
+// streamCSV writes rows to the client as they are read instead of buffering
+// the whole table. The export runs on the request context rather than
+// dbContext so large tables are bounded by EXPORT_TIMEOUT instead of the
+// per-query timeout. Once the first byte is sent the status can no longer
+// change, so a failure part way through is logged and the response is simply
+// truncated.
+func streamCSV(c *gin.Context, filename string, header []string, rows *sql.Rows, record func(*sql.Rows) ([]string, error)) {
+	defer rows.Close()
+
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", "attachment; filename="+filename)
+	c.Status(http.StatusOK)
+
+	w := csv.NewWriter(c.Writer)
+	if err := w.Write(header); err != nil {
+		log.Printf("CSV export %s failed: %v", filename, err)
+		return
+	}
+
+	written := 0
+	for rows.Next() {
+		fields, err := record(rows)
+		if err != nil {
+			log.Printf("CSV export %s failed after %d rows: %v", filename, written, err)
+			return
+		}
+		if err := w.Write(fields); err != nil {
+			log.Printf("CSV export %s failed after %d rows: %v", filename, written, err)
+			return
+		}
+		written++
+		if written%csvFlushEvery == 0 {
+			w.Flush()
+			c.Writer.Flush()
+		}
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("CSV export %s failed after %d rows: %v", filename, written, err)
+	}
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		log.Printf("CSV export %s failed after %d rows: %v", filename, written, err)
+	}
+}
+
+func formatUint(v uint) string {
+	return strconv.FormatUint(uint64(v), 10)
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// jsonField locates one JSON key on its struct.
+type jsonField struct {
+	index     int
+	omitEmpty bool
+}
+
+// jsonFields maps the JSON keys of struct type T to their fields. It is the
+// whitelist for ?fields=, and since every key is also its column's name it
+// doubles as the list of columns that may be selected.
+func jsonFields[T any]() map[string]jsonField {
+	fields := map[string]jsonField{}
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	for i := 0; i < t.NumField(); i++ {
+		name, opts, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			fields[name] = jsonField{index: i, omitEmpty: opts == "omitempty"}
+		}
+	}
+	return fields
+}
+
+// parseFields reads ?fields=a,b. A nil result means every field; otherwise
+// the id field is always included, first, so rows stay addressable.
+func parseFields(c *gin.Context, allowed map[string]jsonField, id string) ([]string, error) {
+	v := c.Query("fields")
+	if v == "" {
+		return nil, nil
+	}
+	fields := []string{id}
+	seen := map[string]bool{id: true}
+	for _, field := range strings.Split(v, ",") {
+		field = strings.TrimSpace(field)
+		if _, ok := allowed[field]; !ok {
+			return nil, fmt.Errorf("unknown field %q in fields", field)
+		}
+		if !seen[field] {
+			seen[field] = true
+			fields = append(fields, field)
+		}
+	}
+	return fields, nil
+}
+
+// scanFields returns a scan function for rows that select just the given
+// columns, which fills the matching fields of T and leaves the rest zero.
+func scanFields[T any](fields []string) func(rowScanner) (T, error) {
+	all := jsonFields[T]()
+	return func(row rowScanner) (T, error) {
+		var item T
+		v := reflect.ValueOf(&item).Elem()
+		dest := make([]interface{}, len(fields))
+		for i, field := range fields {
+			dest[i] = v.Field(all[field].index).Addr().Interface()
+		}
+		err := row.Scan(dest...)
+		return item, err
+	}
+}
+
+// fieldPicker returns a function reducing a T to the named JSON keys. Keys
+// the value omits, such as an empty deleted_at, stay omitted.
+func fieldPicker[T any](fields []string) func(T) map[string]interface{} {
+	all := jsonFields[T]()
+	return func(item T) map[string]interface{} {
+		v := reflect.ValueOf(item)
+		picked := make(map[string]interface{}, len(fields))
+		for _, field := range fields {
+			f := all[field]
+			value := v.Field(f.index)
+			if f.omitEmpty && value.IsZero() {
+				continue
+			}
+			picked[field] = value.Interface()
+		}
+		return picked
+	}
+}
+
+// pickAll reduces every item to the named JSON keys, returning items
+// unchanged when no fields were asked for.
+func pickAll[T any](items []T, fields []string) interface{} {
+	if fields == nil {
+		return items
+	}
+	pick := fieldPicker[T](fields)
+	picked := make([]map[string]interface{}, 0, len(items))
+	for _, item := range items {
+		picked = append(picked, pick(item))
+	}
+	return picked
+}
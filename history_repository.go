@@ -0,0 +1,237 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const historyColumns = "history_id, history_name, history_price, created_at"
+
+type HistoryFilter struct {
+	// Name matches history_name case-insensitively; % and _ are literal.
+	Name string
+	// From and To bound created_at inclusively; nil leaves that side open.
+	From   *time.Time
+	To     *time.Time
+	Limit  int
+	Offset int
+}
+
+type HistoryRepository struct {
+	db *timedDB
+}
+
+func NewHistoryRepository(db *timedDB) *HistoryRepository {
+	return &HistoryRepository{db: db}
+}
+
+func scanHistory(row rowScanner) (History, error) {
+	var history History
+	err := row.Scan(&history.ID, &history.Name, &history.Price, &history.CreatedAt)
+	return history, err
+}
+
+// List returns one page of history entries matching the filter, newest
+// first, along with the total number of matching entries.
+func (r *HistoryRepository) List(ctx context.Context, filter HistoryFilter) (histories []History, total int, err error) {
+	err = retryRead(ctx, func() error {
+		var err error
+		histories, total, err = r.list(ctx, filter)
+		return err
+	})
+	return histories, total, err
+}
+
+func (r *HistoryRepository) list(ctx context.Context, filter HistoryFilter) ([]History, int, error) {
+	where := historyWhere(filter)
+
+	var total int
+	if err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM history"+where.String(), where.args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM history%s ORDER BY history_id DESC LIMIT $%d OFFSET $%d", historyColumns, where, len(where.args)+1, len(where.args)+2)
+	rows, err := r.db.QueryContext(ctx, query, append(where.args, filter.Limit, filter.Offset)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	histories, err := scanHistories(rows)
+	return histories, total, err
+}
+
+// ListAfter returns up to filter.Limit entries matching the filter that come
+// after the entry with id after in the newest-first order, i.e. are older
+// than it, ignoring filter.Offset. more reports whether further entries
+// follow.
+func (r *HistoryRepository) ListAfter(ctx context.Context, filter HistoryFilter, after uint64) (histories []History, more bool, err error) {
+	where := historyWhere(filter)
+	where.add("history_id < $%d", after)
+	query := fmt.Sprintf("SELECT %s FROM history%s ORDER BY history_id DESC LIMIT $%d", historyColumns, where, len(where.args)+1)
+
+	err = retryRead(ctx, func() error {
+		rows, err := r.db.QueryContext(ctx, query, append(where.args, filter.Limit+1)...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		histories, err = scanHistories(rows)
+		return err
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	if len(histories) > filter.Limit {
+		return histories[:filter.Limit], true, nil
+	}
+	return histories, false, nil
+}
+
+func historyWhere(filter HistoryFilter) *whereClause {
+	where := &whereClause{}
+	if filter.Name != "" {
+		where.add("history_name ILIKE $%d", escapeLike(filter.Name))
+	}
+	if filter.From != nil {
+		where.add("created_at >= $%d", *filter.From)
+	}
+	if filter.To != nil {
+		where.add("created_at <= $%d", *filter.To)
+	}
+	return where
+}
+
+func scanHistories(rows *sql.Rows) ([]History, error) {
+	histories := []History{}
+	for rows.Next() {
+		history, err := scanHistory(rows)
+		if err != nil {
+			return nil, err
+		}
+		histories = append(histories, history)
+	}
+	return histories, rows.Err()
+}
+
+func (r *HistoryRepository) Count(ctx context.Context) (int, error) {
+	return retryReadValue(ctx, func() (int, error) {
+		var count int
+		err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM history").Scan(&count)
+		return count, err
+	})
+}
+
+func (r *HistoryRepository) Insert(ctx context.Context, history History) (History, error) {
+	return scanHistory(r.db.QueryRowContext(ctx, "INSERT INTO history (history_name, history_price) VALUES ($1, $2) RETURNING "+historyColumns, history.Name, history.Price))
+}
+
+// InsertUnlessRecent inserts history unless an entry with the same name and
+// price was created within window, in which case that entry is returned and
+// created is false. Concurrent inserts of the same name are serialised with a
+// transaction-scoped advisory lock so retries racing each other still collapse
+// into one row.
+func (r *HistoryRepository) InsertUnlessRecent(ctx context.Context, history History, window time.Duration) (History, bool, error) {
+	if window <= 0 {
+		inserted, err := r.Insert(ctx, history)
+		return inserted, true, err
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return History{}, false, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock(hashtext($1))", history.Name); err != nil {
+		return History{}, false, err
+	}
+
+	existing, err := scanHistory(tx.QueryRowContext(ctx, "SELECT "+historyColumns+" FROM history WHERE history_name = $1 AND history_price = $2 AND created_at > now() - make_interval(secs => $3) ORDER BY history_id DESC LIMIT 1", history.Name, history.Price, window.Seconds()))
+	if err == nil {
+		return existing, false, tx.Commit()
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return History{}, false, err
+	}
+
+	inserted, err := scanHistory(tx.QueryRowContext(ctx, "INSERT INTO history (history_name, history_price) VALUES ($1, $2) RETURNING "+historyColumns, history.Name, history.Price))
+	if err != nil {
+		return History{}, false, err
+	}
+	return inserted, true, tx.Commit()
+}
+
+// InsertFromTrain copies the name and price of a live train into a new history
+// entry within one transaction.
+func (r *HistoryRepository) InsertFromTrain(ctx context.Context, trainID uint64) (History, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return History{}, err
+	}
+	defer tx.Rollback()
+
+	var name string
+	var price Money
+	err = tx.QueryRowContext(ctx, "SELECT train_name, train_price FROM trains WHERE train_id=$1 AND deleted_at IS NULL FOR SHARE", trainID).Scan(&name, &price)
+	if errors.Is(err, sql.ErrNoRows) {
+		return History{}, ErrNotFound
+	}
+	if err != nil {
+		return History{}, err
+	}
+
+	history, err := scanHistory(tx.QueryRowContext(ctx, "INSERT INTO history (history_name, history_price) VALUES ($1, $2) RETURNING "+historyColumns, name, price))
+	if err != nil {
+		return History{}, err
+	}
+	return history, tx.Commit()
+}
+
+func (r *HistoryRepository) Update(ctx context.Context, id uint64, history History) (History, error) {
+	updated, err := scanHistory(r.db.QueryRowContext(ctx, "UPDATE history SET history_name=$1, history_price=$2 WHERE history_id=$3 RETURNING "+historyColumns, history.Name, history.Price, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return History{}, ErrNotFound
+	}
+	return updated, err
+}
+
+func (r *HistoryRepository) Delete(ctx context.Context, id uint64) error {
+	result, err := r.db.ExecContext(ctx, "DELETE FROM history WHERE history_id = $1", id)
+	if err != nil {
+		return err
+	}
+	return checkRowsAffected(result)
+}
+
+// Cursor returns every history row in id order for callers that stream results
+// instead of loading them into memory. The caller must close the rows.
+func (r *HistoryRepository) Cursor(ctx context.Context) (*sql.Rows, error) {
+	return r.db.QueryContext(ctx, "SELECT "+historyColumns+" FROM history ORDER BY history_id")
+}
+
+// Clear removes every history entry and resets the id sequence, returning the
+// number of rows that were removed.
+func (r *HistoryRepository) Clear(ctx context.Context) (int, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "LOCK TABLE history IN ACCESS EXCLUSIVE MODE"); err != nil {
+		return 0, err
+	}
+
+	var removed int
+	if err := tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM history").Scan(&removed); err != nil {
+		return 0, err
+	}
+	if _, err := tx.ExecContext(ctx, "TRUNCATE history RESTART IDENTITY"); err != nil {
+		return 0, err
+	}
+	return removed, tx.Commit()
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const idempotencyHeader = "Idempotency-Key"
+
+type idempotentResponse struct {
+	status      int
+	contentType string
+	body        []byte
+	// bodyHash is the SHA-256 of the request body the key was first used with.
+	bodyHash [sha256.Size]byte
+	inFlight bool
+	expires  time.Time
+}
+
+// idempotencyStore remembers responses by user, endpoint and Idempotency-Key
+// so a retried request gets the original answer instead of inserting again.
+type idempotencyStore struct {
+	mu        sync.Mutex
+	responses map[string]*idempotentResponse
+	ttl       time.Duration
+}
+
+func newIdempotencyStore(ttl time.Duration) *idempotencyStore {
+	s := &idempotencyStore{
+		responses: make(map[string]*idempotentResponse),
+		ttl:       ttl,
+	}
+	go s.evictExpired()
+	return s
+}
+
+func (s *idempotencyStore) evictExpired() {
+	for range time.Tick(time.Minute) {
+		now := time.Now()
+		s.mu.Lock()
+		for key, resp := range s.responses {
+			if !resp.inFlight && now.After(resp.expires) {
+				delete(s.responses, key)
+			}
+		}
+		s.mu.Unlock()
+	}
+}
+
+// begin returns the stored response for key, or reserves the key for a
+// request with the given body hash and returns nil if this is the first
+// request using it.
+func (s *idempotencyStore) begin(key string, bodyHash [sha256.Size]byte) *idempotentResponse {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if resp, ok := s.responses[key]; ok && (resp.inFlight || time.Now().Before(resp.expires)) {
+		return resp
+	}
+	s.responses[key] = &idempotentResponse{inFlight: true, bodyHash: bodyHash}
+	return nil
+}
+
+func (s *idempotencyStore) finish(key string, bodyHash [sha256.Size]byte, status int, contentType string, body []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Server errors are not replayed so the client can retry them.
+	if status >= http.StatusInternalServerError {
+		delete(s.responses, key)
+		return
+	}
+	s.responses[key] = &idempotentResponse{
+		status:      status,
+		contentType: contentType,
+		body:        body,
+		bodyHash:    bodyHash,
+		expires:     time.Now().Add(s.ttl),
+	}
+}
+
+func (s *idempotencyStore) release(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.responses, key)
+}
+
+type recordingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *recordingWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *recordingWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+func idempotencyMiddleware(store *idempotencyStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(idempotencyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+
+		// The body is read here to be fingerprinted and put back for the handler.
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			respondBindError(c, err)
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		bodyHash := sha256.Sum256(body)
+
+		storeKey := currentUser(c) + " " + c.Request.Method + " " + c.FullPath() + " " + key
+		if resp := store.begin(storeKey, bodyHash); resp != nil {
+			if resp.bodyHash != bodyHash {
+				c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{"error": "This Idempotency-Key was already used with a different request body"})
+				return
+			}
+			if resp.inFlight {
+				c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "A request with this Idempotency-Key is already in progress"})
+				return
+			}
+			c.Header("Idempotent-Replayed", "true")
+			c.Data(resp.status, resp.contentType, resp.body)
+			c.Abort()
+			return
+		}
+
+		writer := &recordingWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		completed := false
+		defer func() {
+			// A panicking handler must not leave the key reserved forever.
+			if !completed {
+				store.release(storeKey)
+			}
+		}()
+		c.Next()
+
+		store.finish(storeKey, bodyHash, writer.Status(), writer.Header().Get("Content-Type"), writer.body.Bytes())
+		completed = true
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"log"
+	"log/slog"
+	"net/http"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setupLogger installs a JSON slog handler as the default logger. Output from
+// the standard log package is routed through it as well.
+func setupLogger() {
+	level := slog.LevelInfo
+	if v := os.Getenv("LOG_LEVEL"); v != "" {
+		if err := level.UnmarshalText([]byte(v)); err != nil {
+			log.Fatalf("Invalid LOG_LEVEL %q: must be one of debug, info, warn, error", v)
+		}
+	}
+
+	handler := slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: level})
+	slog.SetDefault(slog.New(handler))
+}
+
+// dbErrorKey marks a request that went through handleDBError so the access
+// log never samples it out.
+const dbErrorKey = "db_error"
+
+// requestLogger writes one access-log line per request. With sampleEvery > 1
+// only one in sampleEvery ordinary requests is logged; errors, database
+// failures and requests slower than slow are always logged.
+func requestLogger(sampleEvery uint64, slow time.Duration) gin.HandlerFunc {
+	var seen atomic.Uint64
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		status := c.Writer.Status()
+		latency := time.Since(start)
+		always := status >= http.StatusBadRequest || c.GetBool(dbErrorKey) || (slow > 0 && latency >= slow)
+		if !always && sampleEvery > 1 && seen.Add(1)%sampleEvery != 0 {
+			return
+		}
+
+		level := slog.LevelInfo
+		if status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		} else if status >= http.StatusBadRequest {
+			level = slog.LevelWarn
+		}
+
+		slog.Log(c.Request.Context(), level, "request",
+			"request_id", c.GetString(requestIDKey),
+			"method", c.Request.Method,
+			"path", c.Request.URL.Path,
+			"route", c.FullPath(),
+			"status", status,
+			"latency_ms", latency.Milliseconds(),
+			"client_ip", c.ClientIP(),
+			"user", currentUser(c),
+			"cache", c.GetString(cacheStatusKey),
+			"bytes", c.Writer.Size(),
+		)
+	}
+}
@@ -1,292 +1,1161 @@
-package main
-
-import (
-	"database/sql"
-	"fmt"
-	"log"
-	"net/http"
-	"os"
-
-	"github.com/gin-gonic/gin"
-	"github.com/joho/godotenv"
-	_ "github.com/lib/pq"
-)
-
-type Train struct {
-	ID    uint   `json:"train_id"`
-	Name  string `json:"train_name"`
-	Price uint   `json:"train_price"`
-}
-
-type Plane struct {
-	ID    uint   `json:"plane_id"`
-	Name  string `json:"plane_name"`
-	Price uint   `json:"plane_price"`
-}
-
-type History struct {
-	ID    uint   `json:"history_id"`
-	Name  string `json:"history_name"`
-	Price uint   `json:"history_price"`
-}
-
-var db *sql.DB
-
-func init() {
-	if err := godotenv.Load(); err != nil {
-		log.Fatal("Error loading .env file")
-	}
-}
-
-func main() {
-	dbUsername := os.Getenv("DATABASE_USERNAME")
-	dbPassword := os.Getenv("DATABASE_PASSWORD")
-	dbHost := os.Getenv("DATABASE_HOST")
-	dbPort := os.Getenv("DATABASE_PORT")
-	dbName := os.Getenv("DATABASE_NAME")
-
-	dsn := fmt.Sprintf("user=%s password=%s host=%s port=%s dbname=%s sslmode=require", dbUsername, dbPassword, dbHost, dbPort, dbName)
-
-	var err error
-	db, err = sql.Open("postgres", dsn)
-	if err != nil {
-		log.Fatalf("Failed to connect to database: %v", err)
-	}
-	defer db.Close()
-
-	if err := createTrainsTable(); err != nil {
-		log.Fatalf("Failed to create trains table: %v", err)
-	}
-
-	if err := createPlanesTable(); err != nil {
-		log.Fatalf("Failed to create planes table: %v", err)
-	}
-
-	if err := createHistoryTable(); err != nil {
-		log.Fatalf("Failed to create history table: %v", err)
-	}
-
-	router := gin.Default()
-
-	router.Use(corsMiddleware())
-
-	router.GET("/", homePage)
-	router.GET("/trains", getAllTrains)
-	router.GET("/planes", getAllPlanes)
-	router.GET("/history", getHistory)
-
-	router.POST("/trains/add", insertTrain)
-	router.POST("/planes/add", insertPlane)
-	router.POST("/history/add", insertHistory)
-
-	router.DELETE("/trains/:id", deleteTrain)
-	router.DELETE("/planes/:id", deletePlane)
-	router.DELETE("/history/:id", deleteHistory)
-
-	port := os.Getenv("PORT")
-	router.Run(":" + port)
-}
-
-func corsMiddleware() gin.HandlerFunc {
-	return func(c *gin.Context) {
-
-		c.Header("Access-Control-Allow-Origin", "*")
-		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization")
-		c.Header("Access-Control-Allow-Credentials", "true")
-
-		if c.Request.Method == "OPTIONS" {
-			c.AbortWithStatus(200)
-			return
-		}
-
-		c.Next()
-	}
-}
-
-func createTrainsTable() error {
-	query := `
-        CREATE TABLE IF NOT EXISTS trains (
-            train_id SERIAL PRIMARY KEY,
-            train_name VARCHAR(100) NOT NULL,
-            train_price INTEGER NOT NULL
-        );
-    `
-	_, err := db.Exec(query)
-	return err
-}
-
-func createPlanesTable() error {
-	query := `
-        CREATE TABLE IF NOT EXISTS planes (
-            plane_id SERIAL PRIMARY KEY,
-            plane_name VARCHAR(100) NOT NULL,
-            plane_price INTEGER NOT NULL
-        );
-    `
-	_, err := db.Exec(query)
-	return err
-}
-
-func createHistoryTable() error {
-	query := `
-        CREATE TABLE IF NOT EXISTS history (
-            history_id SERIAL PRIMARY KEY,
-            history_name VARCHAR(100) NOT NULL,
-            history_price INTEGER NOT NULL
-        );
-    `
-	_, err := db.Exec(query)
-	return err
-}
-
-func homePage(c *gin.Context) {
-	c.String(http.StatusOK, "Welcome to my application!")
-}
-
-func getAllTrains(c *gin.Context) {
-	rows, err := db.Query("SELECT * FROM trains")
-	if err != nil {
-		handleDBError(c, err)
-		return
-	}
-	defer rows.Close()
-
-	var trains []Train
-	for rows.Next() {
-		var train Train
-		err := rows.Scan(&train.ID, &train.Name, &train.Price)
-		if err != nil {
-			handleDBError(c, err)
-			return
-		}
-		trains = append(trains, train)
-	}
-	c.JSON(http.StatusOK, trains)
-}
-
-func getAllPlanes(c *gin.Context) {
-	rows, err := db.Query("SELECT * FROM planes")
-	if err != nil {
-		handleDBError(c, err)
-		return
-	}
-	defer rows.Close()
-
-	var planes []Plane
-	for rows.Next() {
-		var plane Plane
-		err := rows.Scan(&plane.ID, &plane.Name, &plane.Price)
-		if err != nil {
-			handleDBError(c, err)
-			return
-		}
-		planes = append(planes, plane)
-	}
-	c.JSON(http.StatusOK, planes)
-}
-
-func getHistory(c *gin.Context) {
-	rows, err := db.Query("SELECT * FROM history")
-	if err != nil {
-		handleDBError(c, err)
-		return
-	}
-	defer rows.Close()
-
-	var histories []History
-	for rows.Next() {
-		var history History
-		err := rows.Scan(&history.ID, &history.Name, &history.Price)
-		if err != nil {
-			handleDBError(c, err)
-			return
-		}
-		histories = append(histories, history)
-	}
-	c.JSON(http.StatusOK, histories)
-}
-
-func insertTrain(c *gin.Context) {
-	var newTrain Train
-	if err := c.BindJSON(&newTrain); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-
-	_, err := db.Exec("INSERT INTO trains (train_name, train_price) VALUES ($1, $2)", newTrain.Name, newTrain.Price)
-	if err != nil {
-		handleDBError(c, err)
-		return
-	}
-
-	c.JSON(http.StatusCreated, gin.H{"message": "Train created successfully"})
-}
-
-func insertPlane(c *gin.Context) {
-	var newPlane Plane
-	if err := c.BindJSON(&newPlane); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-
-	_, err := db.Exec("INSERT INTO planes (plane_name, plane_price) VALUES ($1, $2)", newPlane.Name, newPlane.Price)
-	if err != nil {
-		handleDBError(c, err)
-		return
-	}
-
-	c.JSON(http.StatusCreated, gin.H{"message": "Plane created successfully"})
-}
-
-
-func insertHistory(c *gin.Context) {
-	var newHistory History
-	if err := c.BindJSON(&newHistory); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-
-	_, err := db.Exec("INSERT INTO history (history_name, history_price) VALUES ($1, $2)", newHistory.Name, newHistory.Price)
-	if err != nil {
-		handleDBError(c, err)
-		return
-	}
-
-	c.JSON(http.StatusCreated, gin.H{"message": "Added to history created successfully"})
-}
-
-func handleDBError(c *gin.Context, err error) {
-	log.Printf("Database error: %v", err)
-	c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
-}
-
-func deleteTrain(c *gin.Context) {
-	id := c.Param("id")
-	_, err := db.Exec("DELETE FROM trains WHERE train_id = $1", id)
-	if err != nil {
-		handleDBError(c, err)
-		return
-	}
-	c.JSON(http.StatusOK, gin.H{"message": "Train deleted successfully"})
-}
-
-func deleteHistory(c *gin.Context) {
-	id := c.Param("id")
-	_, err := db.Exec("DELETE FROM history WHERE history_id = $1", id)
-	if err != nil {
-		handleDBError(c, err)
-		return
-	}
-	c.JSON(http.StatusOK, gin.H{"message": "History deleted successfully"})
-}
-
-func deletePlane(c *gin.Context) {
-	id := c.Param("id")
-	_, err := db.Exec("DELETE FROM planes WHERE plane_id = $1", id)
-	if err != nil {
-		handleDBError(c, err)
-		return
-	}
-	c.JSON(http.StatusOK, gin.H{"message": "Plane deleted successfully"})
-}
\ No newline at end of file
+package main
+
+import (
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"encoding/xml"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"example.com/DB_project/docs"
+	"github.com/gin-gonic/gin"
+	"github.com/joho/godotenv"
+	"github.com/lib/pq"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	swaggerFiles "github.com/swaggo/files"
+	ginSwagger "github.com/swaggo/gin-swagger"
+	"golang.org/x/time/rate"
+)
+
+type Train struct {
+	XMLName   xml.Name   `json:"-" xml:"train"`
+	ID        uint       `json:"train_id" xml:"train_id"`
+	Name      string     `json:"train_name" xml:"train_name" binding:"required,notblank"`
+	Price     Money      `json:"train_price" xml:"train_price" swaggertype:"number" example:"19.99"`
+	Currency  string     `json:"currency" xml:"currency" binding:"omitempty,currency"`
+	Version   uint       `json:"version" xml:"version"`
+	CreatedAt time.Time  `json:"created_at" xml:"created_at"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty" xml:"deleted_at,omitempty"`
+}
+
+type Plane struct {
+	XMLName   xml.Name   `json:"-" xml:"plane"`
+	ID        uint       `json:"plane_id" xml:"plane_id"`
+	Name      string     `json:"plane_name" xml:"plane_name" binding:"required,notblank"`
+	Price     Money      `json:"plane_price" xml:"plane_price" swaggertype:"number" example:"19.99"`
+	Currency  string     `json:"currency" xml:"currency" binding:"omitempty,currency"`
+	Version   uint       `json:"version" xml:"version"`
+	CreatedAt time.Time  `json:"created_at" xml:"created_at"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty" xml:"deleted_at,omitempty"`
+}
+
+type Bus struct {
+	XMLName   xml.Name   `json:"-" xml:"bus"`
+	ID        uint       `json:"bus_id" xml:"bus_id"`
+	Name      string     `json:"bus_name" xml:"bus_name" binding:"required,notblank"`
+	Price     Money      `json:"bus_price" xml:"bus_price" swaggertype:"number" example:"19.99"`
+	Currency  string     `json:"currency" xml:"currency" binding:"omitempty,currency"`
+	Version   uint       `json:"version" xml:"version"`
+	CreatedAt time.Time  `json:"created_at" xml:"created_at"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty" xml:"deleted_at,omitempty"`
+}
+
+type History struct {
+	XMLName   xml.Name  `json:"-" xml:"history"`
+	ID        uint      `json:"history_id" xml:"history_id"`
+	Name      string    `json:"history_name" xml:"history_name" binding:"required,notblank"`
+	Price     Money     `json:"history_price" xml:"history_price" swaggertype:"number" example:"19.99"`
+	CreatedAt time.Time `json:"created_at" xml:"created_at"`
+}
+
+type Booking struct {
+	ID        uint      `json:"booking_id"`
+	Type      string    `json:"booking_type"`
+	ItemID    uint      `json:"item_id"`
+	Name      string    `json:"booking_name"`
+	Price     Money     `json:"booking_price" swaggertype:"number" example:"19.99"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+var db *timedDB
+
+var (
+	trainRepo   *TrainRepository
+	planeRepo   *PlaneRepository
+	busRepo     *BusRepository
+	historyRepo *HistoryRepository
+	bookingRepo *BookingRepository
+)
+
+var minPrice Money = 1
+
+// sslModes lists the sslmode values supported by lib/pq.
+var sslModes = map[string]bool{
+	"disable":     true,
+	"require":     true,
+	"verify-ca":   true,
+	"verify-full": true,
+}
+
+var queryTimeout = 5 * time.Second
+
+// historyDedupWindow is how far back insertHistory looks for an identical
+// entry to return instead of inserting a duplicate; zero disables the check.
+var historyDedupWindow = 5 * time.Second
+
+var (
+	trainCache *listCache
+	planeCache *listCache
+	busCache   *listCache
+)
+
+const (
+	shutdownTimeout = 10 * time.Second
+	healthTimeout   = 2 * time.Second
+)
+
+// init loads .env for local development. In production the variables are
+// expected to come from the real environment, so a missing file is fine.
+func init() {
+	if _, err := os.Stat(".env"); errors.Is(err, os.ErrNotExist) {
+		log.Println("WARNING: no .env file found, using the process environment")
+		return
+	}
+	if err := godotenv.Load(); err != nil {
+		log.Fatalf("Error loading .env file: %v", err)
+	}
+}
+
+func main() {
+	seed := flag.Bool("seed", false, "insert sample trains, planes and history into empty tables, then exit")
+	flag.Parse()
+
+	setupLogger()
+	checkRequiredEnv()
+
+	dbUsername := os.Getenv("DATABASE_USERNAME")
+	dbPassword := os.Getenv("DATABASE_PASSWORD")
+	dbHost := os.Getenv("DATABASE_HOST")
+	dbPort := os.Getenv("DATABASE_PORT")
+	dbName := os.Getenv("DATABASE_NAME")
+
+	if v := os.Getenv("MIN_PRICE"); v != "" {
+		parsed, err := ParseMoney(v)
+		if err != nil || parsed == 0 {
+			log.Fatalf("Invalid MIN_PRICE %q: must be a positive amount such as 1 or 0.50", v)
+		}
+		minPrice = parsed
+	}
+	loadValidationRules(map[string]string{
+		"train":   "TRAINS",
+		"plane":   "PLANES",
+		"bus":     "BUSES",
+		"history": "HISTORY",
+	})
+	if codes := envList("SUPPORTED_CURRENCIES"); len(codes) > 0 {
+		supportedCurrencies = map[string]bool{}
+		for _, code := range codes {
+			if len(code) != 3 {
+				log.Fatalf("Invalid SUPPORTED_CURRENCIES entry %q: must be a three-letter ISO 4217 code", code)
+			}
+			supportedCurrencies[strings.ToUpper(code)] = true
+		}
+	}
+	if v := os.Getenv("BASE_CURRENCY"); v != "" {
+		baseCurrency = strings.ToUpper(v)
+	}
+	if !supportedCurrencies[baseCurrency] {
+		log.Fatalf("Invalid BASE_CURRENCY %q: must be one of %s", baseCurrency, supportedCurrencyList())
+	}
+	if err := registerValidators(); err != nil {
+		log.Fatalf("Failed to register validators: %v", err)
+	}
+
+	dbSSLMode := os.Getenv("DATABASE_SSLMODE")
+	if dbSSLMode == "" {
+		dbSSLMode = "require"
+	}
+	if !sslModes[dbSSLMode] {
+		log.Fatalf("Invalid DATABASE_SSLMODE %q: must be one of disable, require, verify-ca, verify-full", dbSSLMode)
+	}
+
+	dsn := fmt.Sprintf("user=%s password=%s host=%s port=%s dbname=%s sslmode=%s", dbUsername, dbPassword, dbHost, dbPort, dbName, dbSSLMode)
+
+	pool, err := sql.Open("postgres", dsn)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	db = &timedDB{pool}
+	defer db.Close()
+
+	maxOpenConns := envInt("DB_MAX_OPEN_CONNS", 25)
+	maxIdleConns := envInt("DB_MAX_IDLE_CONNS", 5)
+	connMaxLifetime := envDuration("DB_CONN_MAX_LIFETIME", 30*time.Minute)
+	db.SetMaxOpenConns(maxOpenConns)
+	db.SetMaxIdleConns(maxIdleConns)
+	db.SetConnMaxLifetime(connMaxLifetime)
+	queryTimeout = envDuration("DB_QUERY_TIMEOUT", queryTimeout)
+	historyDedupWindow = envDuration("HISTORY_DEDUP_WINDOW", historyDedupWindow)
+	readRetries = envInt("DB_READ_RETRIES", readRetries)
+	readRetryBackoff = envDuration("DB_READ_RETRY_BACKOFF", readRetryBackoff)
+	dbBreaker = newCircuitBreaker(envInt("DB_BREAKER_THRESHOLD", 5), envDuration("DB_BREAKER_COOLDOWN", 10*time.Second))
+	slowQueryThreshold = time.Duration(envInt("SLOW_QUERY_MS", int(slowQueryThreshold/time.Millisecond))) * time.Millisecond
+
+	log.Printf("Database pool: max_open=%d max_idle=%d max_lifetime=%s", maxOpenConns, maxIdleConns, connMaxLifetime)
+
+	if err := waitForDatabase(envInt("DB_CONNECT_ATTEMPTS", 5)); err != nil {
+		log.Fatalf("Failed to reach database: %v", err)
+	}
+
+	trainRepo = NewTrainRepository(db)
+	planeRepo = NewPlaneRepository(db)
+	busRepo = NewBusRepository(db)
+	historyRepo = NewHistoryRepository(db)
+	bookingRepo = NewBookingRepository(db)
+
+	cacheTTL := envDuration("CACHE_TTL", 30*time.Second)
+	trainCache = newListCache(cacheTTL)
+	planeCache = newListCache(cacheTTL)
+	busCache = newListCache(cacheTTL)
+	trainHandlers = &catalogHandlers[Train]{kind: "train", repo: trainRepo.catalogRepository, cache: trainCache, sortOrders: trainSortOrders,
+		defaultSort: envSort("TRAINS_DEFAULT_SORT", trainSortOrders, "id_asc"), events: trainEvents}
+	planeHandlers = &catalogHandlers[Plane]{kind: "plane", repo: planeRepo.catalogRepository, cache: planeCache, sortOrders: planeSortOrders,
+		defaultSort: envSort("PLANES_DEFAULT_SORT", planeSortOrders, "id_asc")}
+	busHandlers = &catalogHandlers[Bus]{kind: "bus", repo: busRepo.catalogRepository, cache: busCache, sortOrders: busSortOrders,
+		defaultSort: envSort("BUSES_DEFAULT_SORT", busSortOrders, "id_asc")}
+
+	if err := runMigrations(context.Background()); err != nil {
+		log.Fatalf("Failed to migrate database: %v", err)
+	}
+
+	if *seed {
+		if err := seedDatabase(context.Background()); err != nil {
+			log.Fatalf("Failed to seed database: %v", err)
+		}
+		return
+	}
+
+	go watchDatabase(envDuration("DB_STATUS_INTERVAL", 15*time.Second))
+
+	router := gin.New()
+
+	// Only X-Forwarded-For headers added by these proxies are believed when
+	// working out c.ClientIP(), which the rate limiter and access log use.
+	// "none" ignores the header entirely.
+	trustedProxies := envList("TRUSTED_PROXIES")
+	if len(trustedProxies) == 0 {
+		trustedProxies = []string{"127.0.0.1", "::1"}
+	} else if len(trustedProxies) == 1 && trustedProxies[0] == "none" {
+		trustedProxies = nil
+	}
+	if err := router.SetTrustedProxies(trustedProxies); err != nil {
+		log.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
+	}
+
+	accessLog := requestLogger(uint64(envInt("ACCESS_LOG_SAMPLE", 1)), envDuration("ACCESS_LOG_SLOW", time.Second))
+	// Metrics sit outside recovery so requests that panic still count, as
+	// the 500s recovery turns them into.
+	router.Use(requestIDMiddleware(), accessLog, metricsMiddleware(), recoveryMiddleware())
+	router.Use(shutdownGuard())
+	router.Use(corsMiddleware(envList("CORS_ALLOWED_ORIGINS")))
+
+	gzipLevel := envInt("GZIP_LEVEL", gzip.DefaultCompression)
+	if gzipLevel > gzip.BestCompression {
+		log.Fatalf("Invalid GZIP_LEVEL %d: must be between 0 and %d", gzipLevel, gzip.BestCompression)
+	}
+	router.Use(gzipMiddleware(gzipLevel, envInt("GZIP_MIN_SIZE", 1024), "/export", "/metrics", "/events"))
+
+	rateLimit := envFloat("RATE_LIMIT_RPS", 10)
+	rateBurst := envInt("RATE_LIMIT_BURST", 20)
+	if rateLimit > 0 {
+		router.Use(rateLimitMiddleware(rate.Limit(rateLimit), rateBurst, "/healthz", "/readyz"))
+	}
+
+	maxBodyBytes := envInt("MAX_BODY_BYTES", 1<<20)
+	if maxBodyBytes < 1 {
+		log.Fatalf("Invalid MAX_BODY_BYTES %d: must be positive", maxBodyBytes)
+	}
+	router.Use(bodyLimit(int64(maxBodyBytes)))
+
+	if envBool("READ_ONLY", false) {
+		log.Println("READ_ONLY is set: writes will be rejected with 503")
+		router.Use(readOnlyMiddleware())
+	}
+
+	router.Use(requestTimeout(envDuration("REQUEST_TIMEOUT", 30*time.Second)))
+	longRunning := overrideTimeout(envDuration("EXPORT_TIMEOUT", 5*time.Minute))
+
+	router.GET("/", homePage)
+	router.GET("/healthz", healthCheck)
+	router.GET("/readyz", readinessCheck)
+	router.GET("/metrics", gin.WrapH(promhttp.Handler()))
+	router.GET("/version", versionInfo)
+	// API_PREFIX mounts the resource routes under a base path such as /api/v1
+	// for reverse proxies; the operational endpoints above stay at the root.
+	api := router.Group("/" + strings.Trim(os.Getenv("API_PREFIX"), "/"))
+	// data holds the routes that need the database, which the breaker turns
+	// away while it is down; the event stream and pool stats stay reachable.
+	data := api.Group("/", dbBreaker.guard())
+	// identify lets admins' tokens unlock include_deleted on the public lists.
+	identify := optionalAuth(os.Getenv("JWT_SECRET"))
+
+	docs.SwaggerInfo.BasePath = api.BasePath()
+	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+
+	api.GET("/trains/events", trainEventStream)
+	data.GET("/trains", identify, getAllTrains)
+	data.GET("/trains/cheapest", cheapestTrains)
+	data.GET("/trains/count", countTrains)
+	data.GET("/trains/export", longRunning, exportTrains)
+	data.GET("/trains/stats", trainStats)
+	data.GET("/trains/prices", trainPrices)
+	data.GET("/trains/:id", getTrainByID)
+	data.GET("/planes", identify, getAllPlanes)
+	data.GET("/planes/count", countPlanes)
+	data.GET("/planes/export", longRunning, exportPlanes)
+	data.GET("/planes/stats", planeStats)
+	data.GET("/planes/prices", planePrices)
+	data.GET("/planes/cheapest", cheapestPlanes)
+	data.GET("/planes/:id", getPlaneByID)
+	data.GET("/buses", identify, getAllBuses)
+	data.GET("/buses/:id", getBusByID)
+	data.GET("/history", getHistory)
+	data.GET("/history/count", countHistory)
+	data.GET("/history/export", longRunning, exportHistory)
+	data.GET("/search", searchAll)
+	data.GET("/compare", comparePrices)
+	data.GET("/cheapest", cheapestOverall)
+	data.GET("/dashboard", dashboard)
+
+	idempotent := idempotencyMiddleware(newIdempotencyStore(envDuration("IDEMPOTENCY_TTL", 10*time.Minute)))
+	adminOnly := requireAdminToken(os.Getenv("ADMIN_TOKEN"))
+
+	auth := authMiddleware(os.Getenv("JWT_SECRET"))
+	writes := data.Group("/", auth, requireJSON())
+
+	writes.POST("/trains/add", idempotent, insertTrain)
+	writes.POST("/trains/bulk", idempotent, bulkInsertTrains)
+	writes.POST("/trains/import", idempotent, importTrains)
+	writes.POST("/planes/add", idempotent, insertPlane)
+	writes.POST("/buses/add", idempotent, insertBus)
+	writes.POST("/history/add", idempotent, insertHistory)
+	writes.POST("/history/from-train/:id", insertHistoryFromTrain)
+	writes.POST("/trains/:id/book", transactional(), bookTrain)
+	writes.POST("/planes/:id/book", transactional(), bookPlane)
+	writes.POST("/trains/:id/restore", restoreTrain)
+	writes.POST("/planes/:id/restore", restorePlane)
+
+	writes.PUT("/trains/:id", updateTrain)
+	writes.PUT("/planes/:id", updatePlane)
+	writes.PUT("/history/:id", updateHistory)
+
+	writes.PATCH("/trains/:id", patchTrain)
+
+	admins := writes.Group("/", requireRole("admin"))
+	admins.DELETE("/trains/:id", deleteTrain)
+	admins.DELETE("/planes/:id", deletePlane)
+	admins.DELETE("/buses/:id", deleteBus)
+	admins.DELETE("/history/:id", deleteHistory)
+	admins.DELETE("/history", adminOnly, clearHistory)
+	admins.POST("/trains/delete-batch", deleteTrainBatch)
+	admins.POST("/planes/delete-batch", deletePlaneBatch)
+	admins.GET("/debug/indexes", debugIndexes)
+
+	ops := api.Group("/", auth, requireRole("admin"))
+	ops.GET("/debug/dbstats", debugDBStats)
+
+	router.HandleMethodNotAllowed = true
+	router.NoMethod(methodNotAllowed(router.Routes()))
+
+	port := os.Getenv("PORT")
+	srv := &http.Server{
+		Addr:    ":" + port,
+		Handler: router,
+	}
+
+	// Listening up front lets a taken port fail with a clear message before
+	// anything is served.
+	listener, err := net.Listen("tcp", srv.Addr)
+	if errors.Is(err, syscall.EADDRINUSE) {
+		log.Fatalf("Port %s is already in use; stop the other process or set PORT to a free port", port)
+	}
+	if err != nil {
+		log.Fatalf("Failed to listen on %s: %v", srv.Addr, err)
+	}
+	log.Printf("Listening on %s", listener.Addr())
+
+	go func() {
+		if err := srv.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("Failed to start server: %v", err)
+		}
+	}()
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+	log.Println("Shutting down server...")
+	shuttingDown.Store(true)
+	trainEvents.close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		log.Printf("Server forced to shut down: %v", err)
+	}
+	if err := db.Close(); err != nil {
+		log.Printf("Failed to close database: %v", err)
+	}
+}
+
+// waitForDatabase pings the database until it answers, backing off between
+// attempts so the service can start alongside a Postgres that is still booting.
+func waitForDatabase(attempts int) error {
+	if attempts < 1 {
+		attempts = 1
+	}
+	backoff := time.Second
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		ctx, cancel := context.WithTimeout(context.Background(), healthTimeout)
+		err = db.PingContext(ctx)
+		cancel()
+		if err == nil {
+			return nil
+		}
+		if attempt < attempts {
+			log.Printf("Database not ready (attempt %d/%d): %v; retrying in %s", attempt, attempts, err, backoff)
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+	}
+	return err
+}
+
+// corsMiddleware only adds CORS headers for origins on the allowlist. A "*"
+// entry allows any origin but, as browsers require, without credentials.
+func corsMiddleware(allowedOrigins []string) gin.HandlerFunc {
+	allowAll := false
+	allowed := make(map[string]bool, len(allowedOrigins))
+	for _, origin := range allowedOrigins {
+		if origin == "*" {
+			allowAll = true
+		}
+		allowed[origin] = true
+	}
+
+	return func(c *gin.Context) {
+		origin := c.GetHeader("Origin")
+		c.Header("Vary", "Origin")
+
+		if origin != "" && (allowAll || allowed[origin]) {
+			if allowed[origin] {
+				c.Header("Access-Control-Allow-Origin", origin)
+				c.Header("Access-Control-Allow-Credentials", "true")
+			} else {
+				c.Header("Access-Control-Allow-Origin", "*")
+			}
+			c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+			c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Request-ID, Idempotency-Key, X-Admin-Token, If-None-Match")
+			c.Header("Access-Control-Expose-Headers", "X-Request-ID, X-Total-Count, Link, ETag")
+		}
+
+		if c.Request.Method == "OPTIONS" {
+			c.AbortWithStatus(200)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// healthCheck is the liveness probe: it only confirms the process is serving
+// requests and never touches the database.
+func healthCheck(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// readinessCheck is the readiness probe: it reports 503 until the database
+// answers a ping, and without pinging while the circuit breaker is open.
+func readinessCheck(c *gin.Context) {
+	if !dbBreaker.allow() {
+		state, retryIn := dbBreaker.status()
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": "unavailable", "error": "database circuit open", "breaker": state, "retry_in": retryIn.Round(time.Second).String()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), healthTimeout)
+	defer cancel()
+
+	err := db.PingContext(ctx)
+	dbBreaker.record(err)
+	breaker, _ := dbBreaker.status()
+	if err != nil {
+		log.Printf("Readiness check failed: %v", err)
+		category := "database unavailable"
+		if errors.Is(err, context.DeadlineExceeded) {
+			category = "database timeout"
+		}
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": "unavailable", "error": category, "breaker": breaker})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "ok", "breaker": breaker})
+}
+
+// @Summary	List trains
+// @Tags	trains
+// @Produce	json,application/xml
+// @Param	limit	query	int	false	"Page size (max 200)"
+// @Param	offset	query	int	false	"Rows to skip"
+// @Param	after	query	int	false	"Cursor: return the rows after this id instead of using offset; the response carries next_cursor"
+// @Param	min_price	query	number	false	"Lowest price"
+// @Param	max_price	query	number	false	"Highest price"
+// @Param	search	query	string	false	"Substring of the name"
+// @Param	sort	query	string	false	"Sort order: id_asc, id_desc, price_asc, price_desc, name_asc or name_desc"
+// @Param	include_deleted	query	bool	false	"Include soft-deleted trains; admins only"
+// @Param	stream	query	bool	false	"Stream every matching row as a JSON array, ignoring paging"
+// @Param	fields	query	string	false	"Comma-separated JSON fields to return; the id is always included, e.g. train_name,train_price"
+// @Success	200	{object}	Page{data=[]Train}
+// @Success	304	"Not modified"
+// @Failure	400	{object}	errorResponse
+// @Failure	401	{object}	errorResponse
+// @Failure	403	{object}	errorResponse
+// @Failure	500	{object}	errorResponse
+// @Router	/trains [get]
+func getAllTrains(c *gin.Context) {
+	trainHandlers.list(c)
+}
+
+// @Summary	Get a train
+// @Tags	trains
+// @Produce	json
+// @Param	id	path	int	true	"Train id"
+// @Success	200	{object}	Train
+// @Failure	400	{object}	errorResponse
+// @Failure	404	{object}	errorResponse
+// @Failure	500	{object}	errorResponse
+// @Router	/trains/{id} [get]
+func getTrainByID(c *gin.Context) {
+	trainHandlers.get(c)
+}
+
+// @Summary	List planes
+// @Tags	planes
+// @Produce	json,application/xml
+// @Param	limit	query	int	false	"Page size (max 200)"
+// @Param	offset	query	int	false	"Rows to skip"
+// @Param	after	query	int	false	"Cursor: return the rows after this id instead of using offset; the response carries next_cursor"
+// @Param	min_price	query	number	false	"Lowest price"
+// @Param	max_price	query	number	false	"Highest price"
+// @Param	search	query	string	false	"Substring of the name"
+// @Param	sort	query	string	false	"Sort order: id_asc, id_desc, price_asc, price_desc, name_asc or name_desc"
+// @Param	include_deleted	query	bool	false	"Include soft-deleted planes; admins only"
+// @Param	stream	query	bool	false	"Stream every matching row as a JSON array, ignoring paging"
+// @Param	fields	query	string	false	"Comma-separated JSON fields to return; the id is always included, e.g. plane_name,plane_price"
+// @Success	200	{object}	Page{data=[]Plane}
+// @Success	304	"Not modified"
+// @Failure	400	{object}	errorResponse
+// @Failure	401	{object}	errorResponse
+// @Failure	403	{object}	errorResponse
+// @Failure	500	{object}	errorResponse
+// @Router	/planes [get]
+func getAllPlanes(c *gin.Context) {
+	planeHandlers.list(c)
+}
+
+// @Summary	Get a plane
+// @Tags	planes
+// @Produce	json
+// @Param	id	path	int	true	"Plane id"
+// @Success	200	{object}	Plane
+// @Failure	400	{object}	errorResponse
+// @Failure	404	{object}	errorResponse
+// @Failure	500	{object}	errorResponse
+// @Router	/planes/{id} [get]
+func getPlaneByID(c *gin.Context) {
+	planeHandlers.get(c)
+}
+
+// @Summary	List history
+// @Tags	history
+// @Produce	json,application/xml
+// @Param	limit	query	int	false	"Page size (max 200)"
+// @Param	offset	query	int	false	"Rows to skip"
+// @Param	after	query	int	false	"Cursor: return the entries older than this id instead of using offset; the response carries next_cursor"
+// @Param	name	query	string	false	"Exact name, case-insensitive"
+// @Param	from	query	string	false	"RFC 3339 lower bound on created_at"
+// @Param	to	query	string	false	"RFC 3339 upper bound on created_at"
+// @Success	200	{object}	Page{data=[]History}
+// @Failure	400	{object}	errorResponse
+// @Failure	500	{object}	errorResponse
+// @Router	/history [get]
+func getHistory(c *gin.Context) {
+	limit, offset, err := parsePagination(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	from, to, err := parseDateRange(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	after, err := parseCursor(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	filter := HistoryFilter{
+		Name:   c.Query("name"),
+		From:   from,
+		To:     to,
+		Limit:  limit,
+		Offset: offset,
+	}
+
+	ctx, cancel := dbContext(c)
+	defer cancel()
+
+	c.Writer.Header().Add("Vary", "Accept")
+	if after != nil {
+		histories, more, err := historyRepo.ListAfter(ctx, filter, *after)
+		if err != nil {
+			handleDBError(c, err)
+			return
+		}
+		page := CursorPage{Data: histories, Limit: limit}
+		if more && len(histories) > 0 {
+			next := uint64(histories[len(histories)-1].ID)
+			page.NextCursor = &next
+		}
+		setCursorHeaders(c, limit, page.NextCursor)
+		if wantsXML(c) {
+			c.XML(http.StatusOK, page)
+			return
+		}
+		c.JSON(http.StatusOK, page)
+		return
+	}
+
+	histories, total, err := historyRepo.List(ctx, filter)
+	if err != nil {
+		handleDBError(c, err)
+		return
+	}
+	setPaginationHeaders(c, total, limit, offset)
+	page := Page{Data: histories, Total: total, Limit: limit, Offset: offset}
+	if wantsXML(c) {
+		c.XML(http.StatusOK, page)
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+// @Summary	Count trains
+// @Tags	trains
+// @Produce	json
+// @Success	200	{object}	countResponse
+// @Failure	500	{object}	errorResponse
+// @Router	/trains/count [get]
+func countTrains(c *gin.Context) {
+	respondCount(c, trainRepo.Count)
+}
+
+// @Summary	Count planes
+// @Tags	planes
+// @Produce	json
+// @Success	200	{object}	countResponse
+// @Failure	500	{object}	errorResponse
+// @Router	/planes/count [get]
+func countPlanes(c *gin.Context) {
+	respondCount(c, planeRepo.Count)
+}
+
+// @Summary	Count history entries
+// @Tags	history
+// @Produce	json
+// @Success	200	{object}	countResponse
+// @Failure	500	{object}	errorResponse
+// @Router	/history/count [get]
+func countHistory(c *gin.Context) {
+	respondCount(c, historyRepo.Count)
+}
+
+func respondCount(c *gin.Context, count func(ctx context.Context) (int, error)) {
+	ctx, cancel := dbContext(c)
+	defer cancel()
+
+	n, err := count(ctx)
+	if err != nil {
+		handleDBError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"count": n})
+}
+
+// @Summary	Train price statistics
+// @Tags	trains
+// @Produce	json
+// @Success	200	{object}	PriceStats
+// @Failure	500	{object}	errorResponse
+// @Router	/trains/stats [get]
+func trainStats(c *gin.Context) {
+	respondStats(c, trainRepo.Stats)
+}
+
+// @Summary	Plane price statistics
+// @Tags	planes
+// @Produce	json
+// @Success	200	{object}	PriceStats
+// @Failure	500	{object}	errorResponse
+// @Router	/planes/stats [get]
+func planeStats(c *gin.Context) {
+	respondStats(c, planeRepo.Stats)
+}
+
+func respondStats(c *gin.Context, stats func(ctx context.Context) (PriceStats, error)) {
+	ctx, cancel := dbContext(c)
+	defer cancel()
+
+	result, err := stats(ctx)
+	if err != nil {
+		handleDBError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// @Summary	Distinct train prices
+// @Tags	trains
+// @Produce	json
+// @Success	200	{array}	number
+// @Failure	500	{object}	errorResponse
+// @Router	/trains/prices [get]
+func trainPrices(c *gin.Context) {
+	respondPrices(c, trainRepo.Prices)
+}
+
+// @Summary	Distinct plane prices
+// @Tags	planes
+// @Produce	json
+// @Success	200	{array}	number
+// @Failure	500	{object}	errorResponse
+// @Router	/planes/prices [get]
+func planePrices(c *gin.Context) {
+	respondPrices(c, planeRepo.Prices)
+}
+
+func respondPrices(c *gin.Context, prices func(ctx context.Context) ([]Money, error)) {
+	ctx, cancel := dbContext(c)
+	defer cancel()
+
+	result, err := prices(ctx)
+	if err != nil {
+		handleDBError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// @Summary	Create a train
+// @Tags	trains
+// @Accept	json
+// @Produce	json
+// @Security	BearerAuth
+// @Param	train	body	Train	true	"Train to create"
+// @Param	Idempotency-Key	header	string	false	"Replay protection key"
+// @Success	201	{object}	Train
+// @Failure	400	{object}	validationErrorResponse
+// @Failure	415	{object}	errorResponse
+// @Failure	401	{object}	errorResponse
+// @Failure	409	{object}	errorResponse
+// @Failure	422	{object}	errorResponse
+// @Failure	500	{object}	errorResponse
+// @Router	/trains/add [post]
+func insertTrain(c *gin.Context) {
+	trainHandlers.insert(c)
+}
+
+// @Summary	Replace a train
+// @Tags	trains
+// @Accept	json
+// @Produce	json
+// @Security	BearerAuth
+// @Param	id	path	int	true	"Train id"
+// @Param	train	body	Train	true	"New values, with the version they were read at"
+// @Success	200	{object}	Train
+// @Failure	400	{object}	validationErrorResponse
+// @Failure	415	{object}	errorResponse
+// @Failure	401	{object}	errorResponse
+// @Failure	404	{object}	errorResponse
+// @Failure	409	{object}	versionConflictResponse{current=Train}	"Name taken, or changed since it was read"
+// @Failure	500	{object}	errorResponse
+// @Router	/trains/{id} [put]
+func updateTrain(c *gin.Context) {
+	trainHandlers.update(c)
+}
+
+// @Summary	Partially update a train
+// @Tags	trains
+// @Accept	json
+// @Produce	json
+// @Security	BearerAuth
+// @Param	id	path	int	true	"Train id"
+// @Param	patch	body	TrainPatch	true	"Fields to change"
+// @Success	200	{object}	Train
+// @Failure	400	{object}	validationErrorResponse
+// @Failure	415	{object}	errorResponse
+// @Failure	401	{object}	errorResponse
+// @Failure	404	{object}	errorResponse
+// @Failure	409	{object}	errorResponse
+// @Failure	500	{object}	errorResponse
+// @Router	/trains/{id} [patch]
+func patchTrain(c *gin.Context) {
+	id, ok := parseID(c, "train")
+	if !ok {
+		return
+	}
+
+	var patch TrainPatch
+	if !bindAndValidate(c, &patch) {
+		return
+	}
+
+	ctx, cancel := dbContext(c)
+	defer cancel()
+
+	train, err := trainRepo.Patch(ctx, id, patch)
+	if errors.Is(err, ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Train not found"})
+		return
+	}
+	if isUniqueViolation(err) {
+		c.JSON(http.StatusConflict, gin.H{"error": "a train with that name already exists"})
+		return
+	}
+	if err != nil {
+		handleDBError(c, err)
+		return
+	}
+
+	trainCache.invalidate()
+	trainEvents.publish(changeEvent{Action: "updated", ID: id, Data: train})
+	c.JSON(http.StatusOK, train)
+}
+
+// @Summary	Create a plane
+// @Tags	planes
+// @Accept	json
+// @Produce	json
+// @Security	BearerAuth
+// @Param	plane	body	Plane	true	"Plane to create"
+// @Param	Idempotency-Key	header	string	false	"Replay protection key"
+// @Success	201	{object}	Plane
+// @Failure	400	{object}	validationErrorResponse
+// @Failure	415	{object}	errorResponse
+// @Failure	401	{object}	errorResponse
+// @Failure	409	{object}	errorResponse
+// @Failure	422	{object}	errorResponse
+// @Failure	500	{object}	errorResponse
+// @Router	/planes/add [post]
+func insertPlane(c *gin.Context) {
+	planeHandlers.insert(c)
+}
+
+// @Summary	Replace a plane
+// @Tags	planes
+// @Accept	json
+// @Produce	json
+// @Security	BearerAuth
+// @Param	id	path	int	true	"Plane id"
+// @Param	plane	body	Plane	true	"New values, with the version they were read at"
+// @Success	200	{object}	Plane
+// @Failure	400	{object}	validationErrorResponse
+// @Failure	415	{object}	errorResponse
+// @Failure	401	{object}	errorResponse
+// @Failure	404	{object}	errorResponse
+// @Failure	409	{object}	versionConflictResponse{current=Plane}	"Name taken, or changed since it was read"
+// @Failure	500	{object}	errorResponse
+// @Router	/planes/{id} [put]
+func updatePlane(c *gin.Context) {
+	planeHandlers.update(c)
+}
+
+// @Summary	Create a history entry
+// @Tags	history
+// @Accept	json
+// @Produce	json
+// @Security	BearerAuth
+// @Param	history	body	History	true	"Entry to create"
+// @Param	Idempotency-Key	header	string	false	"Replay protection key"
+// @Success	200	{object}	History	"Identical entry created within the dedup window"
+// @Success	201	{object}	History
+// @Failure	400	{object}	validationErrorResponse
+// @Failure	415	{object}	errorResponse
+// @Failure	401	{object}	errorResponse
+// @Failure	422	{object}	errorResponse
+// @Failure	500	{object}	errorResponse
+// @Router	/history/add [post]
+func insertHistory(c *gin.Context) {
+	var newHistory History
+	if !bindAndValidate(c, &newHistory) {
+		return
+	}
+
+	ctx, cancel := dbContext(c)
+	defer cancel()
+
+	history, created, err := historyRepo.InsertUnlessRecent(ctx, newHistory, historyDedupWindow)
+	if err != nil {
+		handleDBError(c, err)
+		return
+	}
+
+	if !created {
+		c.JSON(http.StatusOK, history)
+		return
+	}
+	c.JSON(http.StatusCreated, history)
+}
+
+// @Summary	Correct a history entry
+// @Tags	history
+// @Accept	json
+// @Produce	json
+// @Security	BearerAuth
+// @Param	id	path	int	true	"History id"
+// @Param	history	body	History	true	"New values"
+// @Success	200	{object}	History
+// @Failure	400	{object}	validationErrorResponse
+// @Failure	415	{object}	errorResponse
+// @Failure	401	{object}	errorResponse
+// @Failure	404	{object}	errorResponse
+// @Failure	500	{object}	errorResponse
+// @Router	/history/{id} [put]
+func updateHistory(c *gin.Context) {
+	id, ok := parseID(c, "history")
+	if !ok {
+		return
+	}
+
+	var updatedHistory History
+	if !bindAndValidate(c, &updatedHistory) {
+		return
+	}
+
+	ctx, cancel := dbContext(c)
+	defer cancel()
+
+	history, err := historyRepo.Update(ctx, id, updatedHistory)
+	if errors.Is(err, ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "History not found"})
+		return
+	}
+	if err != nil {
+		handleDBError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, history)
+}
+
+// @Summary	Record a train in history
+// @Tags	history
+// @Produce	json
+// @Security	BearerAuth
+// @Param	id	path	int	true	"Train id"
+// @Success	201	{object}	History
+// @Failure	400	{object}	errorResponse
+// @Failure	401	{object}	errorResponse
+// @Failure	404	{object}	errorResponse
+// @Failure	500	{object}	errorResponse
+// @Router	/history/from-train/{id} [post]
+func insertHistoryFromTrain(c *gin.Context) {
+	id, ok := parseID(c, "train")
+	if !ok {
+		return
+	}
+
+	ctx, cancel := dbContext(c)
+	defer cancel()
+
+	history, err := historyRepo.InsertFromTrain(ctx, id)
+	if errors.Is(err, ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Train not found"})
+		return
+	}
+	if err != nil {
+		handleDBError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, history)
+}
+
+func handleDBError(c *gin.Context, err error) {
+	c.Set(dbErrorKey, true)
+	slog.Error("database error",
+		"request_id", c.GetString(requestIDKey),
+		"method", c.Request.Method,
+		"path", c.Request.URL.Path,
+		"user", currentUser(c),
+		"error", err,
+	)
+
+	if isTimeout(err) {
+		if errors.Is(c.Request.Context().Err(), context.DeadlineExceeded) {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Request timed out"})
+			return
+		}
+		c.JSON(http.StatusGatewayTimeout, gin.H{"error": "Database query timed out"})
+		return
+	}
+
+	status, message := classifyDBError(err)
+	c.JSON(status, gin.H{"error": message})
+}
+
+// dbContext bounds the database work of a single request by queryTimeout.
+func dbContext(c *gin.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(c.Request.Context(), queryTimeout)
+}
+
+// isTimeout reports whether err came from a query cut short by its context;
+// lib/pq surfaces that either as the context error or as query_canceled.
+func isTimeout(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "57014"
+}
+
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
+
+// classifyDBError maps integrity constraint violations to client errors so
+// only unexpected database failures surface as 500s.
+func classifyDBError(err error) (int, string) {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return http.StatusInternalServerError, "Database error"
+	}
+
+	switch pqErr.Code {
+	case "23505":
+		return http.StatusConflict, "A record with that value already exists"
+	case "23503":
+		return http.StatusConflict, "The record references a value that does not exist"
+	case "23502":
+		return http.StatusBadRequest, "A required field is missing"
+	case "23514":
+		return http.StatusBadRequest, "A value is outside the allowed range"
+	}
+
+	if pqErr.Code.Class() == "23" {
+		return http.StatusBadRequest, "The request violates a database constraint"
+	}
+	return http.StatusInternalServerError, "Database error"
+}
+
+// @Summary	Soft-delete a train
+// @Tags	trains
+// @Produce	json
+// @Security	BearerAuth
+// @Param	id	path	int	true	"Train id"
+// @Success	200	{object}	messageResponse
+// @Failure	400	{object}	errorResponse
+// @Failure	401	{object}	errorResponse
+// @Failure	403	{object}	errorResponse
+// @Failure	404	{object}	errorResponse
+// @Failure	500	{object}	errorResponse
+// @Router	/trains/{id} [delete]
+func deleteTrain(c *gin.Context) {
+	trainHandlers.delete(c)
+}
+
+// @Summary	Restore a soft-deleted train
+// @Tags	trains
+// @Produce	json
+// @Security	BearerAuth
+// @Param	id	path	int	true	"Train id"
+// @Success	200	{object}	Train
+// @Failure	400	{object}	errorResponse
+// @Failure	401	{object}	errorResponse
+// @Failure	404	{object}	errorResponse
+// @Failure	409	{object}	errorResponse
+// @Failure	500	{object}	errorResponse
+// @Router	/trains/{id}/restore [post]
+func restoreTrain(c *gin.Context) {
+	trainHandlers.restore(c)
+}
+
+// @Summary	Restore a soft-deleted plane
+// @Tags	planes
+// @Produce	json
+// @Security	BearerAuth
+// @Param	id	path	int	true	"Plane id"
+// @Success	200	{object}	Plane
+// @Failure	400	{object}	errorResponse
+// @Failure	401	{object}	errorResponse
+// @Failure	404	{object}	errorResponse
+// @Failure	409	{object}	errorResponse
+// @Failure	500	{object}	errorResponse
+// @Router	/planes/{id}/restore [post]
+func restorePlane(c *gin.Context) {
+	planeHandlers.restore(c)
+}
+
+// @Summary	Delete a history entry
+// @Tags	history
+// @Produce	json
+// @Security	BearerAuth
+// @Param	id	path	int	true	"History id"
+// @Success	200	{object}	messageResponse
+// @Failure	400	{object}	errorResponse
+// @Failure	401	{object}	errorResponse
+// @Failure	403	{object}	errorResponse
+// @Failure	404	{object}	errorResponse
+// @Failure	500	{object}	errorResponse
+// @Router	/history/{id} [delete]
+func deleteHistory(c *gin.Context) {
+	id, ok := parseID(c, "history")
+	if !ok {
+		return
+	}
+
+	ctx, cancel := dbContext(c)
+	defer cancel()
+
+	err := historyRepo.Delete(ctx, id)
+	if errors.Is(err, ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "History not found"})
+		return
+	}
+	if err != nil {
+		handleDBError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "History deleted successfully"})
+}
+
+// @Summary	Soft-delete a plane
+// @Tags	planes
+// @Produce	json
+// @Security	BearerAuth
+// @Param	id	path	int	true	"Plane id"
+// @Success	200	{object}	messageResponse
+// @Failure	400	{object}	errorResponse
+// @Failure	401	{object}	errorResponse
+// @Failure	403	{object}	errorResponse
+// @Failure	404	{object}	errorResponse
+// @Failure	500	{object}	errorResponse
+// @Router	/planes/{id} [delete]
+func deletePlane(c *gin.Context) {
+	planeHandlers.delete(c)
+}
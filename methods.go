@@ -0,0 +1,52 @@
+package main
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// methodNotAllowed answers requests for a known path with an unsupported
+// method, listing the methods that are registered for it in the Allow header.
+// OPTIONS never reaches here because corsMiddleware answers it first.
+func methodNotAllowed(routes gin.RoutesInfo) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		allowed := map[string]bool{http.MethodOptions: true}
+		for _, route := range routes {
+			if routeMatches(route.Path, c.Request.URL.Path) {
+				allowed[route.Method] = true
+			}
+		}
+
+		methods := make([]string, 0, len(allowed))
+		for method := range allowed {
+			methods = append(methods, method)
+		}
+		sort.Strings(methods)
+
+		c.Header("Allow", strings.Join(methods, ", "))
+		c.JSON(http.StatusMethodNotAllowed, gin.H{"error": "Method " + c.Request.Method + " is not allowed on " + c.Request.URL.Path})
+	}
+}
+
+// routeMatches reports whether path fits a gin route pattern with :param and
+// *catchall segments.
+func routeMatches(pattern string, path string) bool {
+	patternParts := strings.Split(strings.Trim(pattern, "/"), "/")
+	pathParts := strings.Split(strings.Trim(path, "/"), "/")
+
+	for i, part := range patternParts {
+		if strings.HasPrefix(part, "*") {
+			return true
+		}
+		if i >= len(pathParts) {
+			return false
+		}
+		if !strings.HasPrefix(part, ":") && part != pathParts[i] {
+			return false
+		}
+	}
+	return len(patternParts) == len(pathParts)
+}
@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS trains (
+    train_id SERIAL PRIMARY KEY,
+    train_name VARCHAR(100) NOT NULL,
+    train_price INTEGER NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS planes (
+    plane_id SERIAL PRIMARY KEY,
+    plane_name VARCHAR(100) NOT NULL,
+    plane_price INTEGER NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS history (
+    history_id SERIAL PRIMARY KEY,
+    history_name VARCHAR(100) NOT NULL,
+    history_price INTEGER NOT NULL
+);
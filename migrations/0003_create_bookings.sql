@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS bookings (
+    booking_id SERIAL PRIMARY KEY,
+    booking_type VARCHAR(10) NOT NULL,
+    item_id INTEGER NOT NULL,
+    booking_name VARCHAR(100) NOT NULL,
+    booking_price INTEGER NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
@@ -0,0 +1,4 @@
+-- This fails if a table already contains duplicate names. Remove or rename
+-- the duplicates and restart; the migration is retried on the next boot.
+CREATE UNIQUE INDEX IF NOT EXISTS trains_train_name_key ON trains (train_name);
+CREATE UNIQUE INDEX IF NOT EXISTS planes_plane_name_key ON planes (plane_name);
@@ -0,0 +1,2 @@
+ALTER TABLE trains ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+ALTER TABLE planes ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
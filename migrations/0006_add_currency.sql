@@ -0,0 +1,4 @@
+-- Existing rows predate currencies and were all priced in US dollars. New rows
+-- always get an explicit code from the application.
+ALTER TABLE trains ADD COLUMN IF NOT EXISTS currency VARCHAR(3) NOT NULL DEFAULT 'USD';
+ALTER TABLE planes ADD COLUMN IF NOT EXISTS currency VARCHAR(3) NOT NULL DEFAULT 'USD';
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS buses (
+    bus_id SERIAL PRIMARY KEY,
+    bus_name VARCHAR(100) NOT NULL,
+    bus_price INTEGER NOT NULL,
+    currency VARCHAR(3) NOT NULL DEFAULT 'USD',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    deleted_at TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS buses_bus_name_key ON buses (bus_name);
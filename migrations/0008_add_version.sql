@@ -0,0 +1,5 @@
+-- version counts the updates to a row so PUT can refuse to overwrite changes
+-- the client has not seen.
+ALTER TABLE trains ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE planes ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE buses ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
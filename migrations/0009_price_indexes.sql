@@ -0,0 +1,4 @@
+-- Price range filters and price sorts would otherwise scan the whole table.
+CREATE INDEX IF NOT EXISTS trains_train_price_idx ON trains (train_price);
+CREATE INDEX IF NOT EXISTS planes_plane_price_idx ON planes (plane_price);
+CREATE INDEX IF NOT EXISTS buses_bus_price_idx ON buses (bus_price);
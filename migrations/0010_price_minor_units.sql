@@ -0,0 +1,7 @@
+-- Prices move from whole units to minor units (cents) so they can carry
+-- decimals: an existing price of 120 becomes 12000, i.e. 120.00.
+ALTER TABLE trains ALTER COLUMN train_price TYPE BIGINT USING train_price * 100;
+ALTER TABLE planes ALTER COLUMN plane_price TYPE BIGINT USING plane_price * 100;
+ALTER TABLE buses ALTER COLUMN bus_price TYPE BIGINT USING bus_price * 100;
+ALTER TABLE history ALTER COLUMN history_price TYPE BIGINT USING history_price * 100;
+ALTER TABLE bookings ALTER COLUMN booking_price TYPE BIGINT USING booking_price * 100;
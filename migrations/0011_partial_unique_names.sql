@@ -0,0 +1,11 @@
+-- Soft-deleted rows should not hold on to their names, so the unique name
+-- indexes only cover live rows. Restoring a row whose name has since been
+-- reused fails against these indexes and is answered with 409.
+DROP INDEX IF EXISTS trains_train_name_key;
+CREATE UNIQUE INDEX trains_train_name_key ON trains (train_name) WHERE deleted_at IS NULL;
+
+DROP INDEX IF EXISTS planes_plane_name_key;
+CREATE UNIQUE INDEX planes_plane_name_key ON planes (plane_name) WHERE deleted_at IS NULL;
+
+DROP INDEX IF EXISTS buses_bus_name_key;
+CREATE UNIQUE INDEX buses_bus_name_key ON buses (bus_name) WHERE deleted_at IS NULL;
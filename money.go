@@ -0,0 +1,97 @@
+package main
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Money is a non-negative amount in minor units, so 19.99 is stored as 1999.
+// In JSON it is written as a number with two decimals and read from either a
+// number or a string, e.g. 19.99 or "19.99".
+type Money int64
+
+const minorUnitsPerMajor = 100
+
+var errInvalidMoney = errors.New("must be a non-negative amount with at most two decimal places")
+
+// ParseMoney reads an amount such as "19", "19.9" or "19.99".
+func ParseMoney(s string) (Money, error) {
+	whole, frac, hasFrac := strings.Cut(s, ".")
+	if !isDigits(whole) || (hasFrac && (len(frac) > 2 || !isDigits(frac))) {
+		return 0, errInvalidMoney
+	}
+	for len(frac) < 2 {
+		frac += "0"
+	}
+
+	units, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil {
+		return 0, errInvalidMoney
+	}
+	cents, err := strconv.ParseInt(frac, 10, 64)
+	if err != nil {
+		return 0, errInvalidMoney
+	}
+	if units > (math.MaxInt64-cents)/minorUnitsPerMajor {
+		return 0, errInvalidMoney
+	}
+	return Money(units*minorUnitsPerMajor + cents), nil
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func (m Money) String() string {
+	sign := ""
+	if m < 0 {
+		sign, m = "-", -m
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, m/minorUnitsPerMajor, m%minorUnitsPerMajor)
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// MarshalText is used by encoding/xml.
+func (m Money) MarshalText() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalJSON reports bad amounts as a *json.UnmarshalTypeError so the
+// decoder fills in the field name and bindErrors can report it.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if s == "null" {
+		return nil
+	}
+	kind := "number"
+	if unquoted, err := strconv.Unquote(s); err == nil {
+		s, kind = unquoted, "string"
+	}
+	parsed, err := ParseMoney(s)
+	if err != nil {
+		return &json.UnmarshalTypeError{Value: kind + " " + s, Type: reflect.TypeOf(*m)}
+	}
+	*m = parsed
+	return nil
+}
+
+func (m Money) Value() (driver.Value, error) {
+	return int64(m), nil
+}
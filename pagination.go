@@ -0,0 +1,118 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultPageLimit = 50
+	maxPageLimit     = 200
+)
+
+// Page is one page of a list. In XML the items appear as repeated elements
+// named after their type, e.g. <page><train>...</train><total>...</total></page>.
+type Page struct {
+	XMLName xml.Name    `json:"-" xml:"page"`
+	Data    interface{} `json:"data" xml:"data"`
+	Total   int         `json:"total" xml:"total"`
+	Limit   int         `json:"limit" xml:"limit"`
+	Offset  int         `json:"offset" xml:"offset"`
+}
+
+// CursorPage is one page of a list fetched with ?after=. NextCursor is the
+// value to pass as after for the following page, absent on the last one.
+// There is no total: counting the table is the cost cursors avoid.
+type CursorPage struct {
+	XMLName    xml.Name    `json:"-" xml:"page"`
+	Data       interface{} `json:"data" xml:"data"`
+	Limit      int         `json:"limit" xml:"limit"`
+	NextCursor *uint64     `json:"next_cursor,omitempty" xml:"next_cursor,omitempty"`
+}
+
+func parsePagination(c *gin.Context) (limit int, offset int, err error) {
+	limit = defaultPageLimit
+	if v := c.Query("limit"); v != "" {
+		limit, err = strconv.Atoi(v)
+		if err != nil || limit < 0 {
+			return 0, 0, fmt.Errorf("limit must be a non-negative integer")
+		}
+		if limit > maxPageLimit {
+			limit = maxPageLimit
+		}
+	}
+
+	if v := c.Query("offset"); v != "" {
+		offset, err = strconv.Atoi(v)
+		if err != nil || offset < 0 {
+			return 0, 0, fmt.Errorf("offset must be a non-negative integer")
+		}
+	}
+
+	return limit, offset, nil
+}
+
+// parseCursor reads ?after=, the id of the last row on the previous page. It
+// returns nil when the parameter is absent, leaving offset pagination in
+// charge.
+func parseCursor(c *gin.Context) (*uint64, error) {
+	v := c.Query("after")
+	if v == "" {
+		return nil, nil
+	}
+	if c.Query("offset") != "" {
+		return nil, fmt.Errorf("after and offset cannot be combined")
+	}
+	// Like parseID, bound to the SERIAL id columns' int4 range.
+	after, err := strconv.ParseUint(v, 10, 31)
+	if err != nil || after == 0 {
+		return nil, fmt.Errorf("after must be a positive integer")
+	}
+	return &after, nil
+}
+
+// setCursorHeaders adds a Link header to the next page of a cursor list.
+func setCursorHeaders(c *gin.Context, limit int, next *uint64) {
+	if next == nil {
+		return
+	}
+	query := c.Request.URL.Query()
+	query.Set("limit", strconv.Itoa(limit))
+	query.Set("after", strconv.FormatUint(*next, 10))
+	c.Header("Link", fmt.Sprintf("<%s?%s>; rel=\"next\"", c.Request.URL.Path, query.Encode()))
+}
+
+// setPaginationHeaders adds X-Total-Count and RFC 5988 Link headers pointing at
+// the neighbouring pages. Other query parameters are carried over unchanged.
+func setPaginationHeaders(c *gin.Context, total int, limit int, offset int) {
+	c.Header("X-Total-Count", strconv.Itoa(total))
+	if limit == 0 {
+		return
+	}
+
+	var links []string
+	if offset+limit < total {
+		links = append(links, pageLink(c, limit, offset+limit, "next"))
+	}
+	if offset > 0 {
+		prev := offset - limit
+		if prev < 0 {
+			prev = 0
+		}
+		links = append(links, pageLink(c, limit, prev, "prev"))
+	}
+	if len(links) > 0 {
+		c.Header("Link", strings.Join(links, ", "))
+	}
+}
+
+func pageLink(c *gin.Context, limit int, offset int, rel string) string {
+	query := c.Request.URL.Query()
+	query.Set("limit", strconv.Itoa(limit))
+	query.Set("offset", strconv.Itoa(offset))
+	return fmt.Sprintf("<%s?%s>; rel=\"%s\"", c.Request.URL.Path, query.Encode(), rel)
+}
@@ -0,0 +1,37 @@
+package main
+
+import "database/sql"
+
+const planeColumns = "plane_id, plane_name, plane_price, currency, version, created_at, deleted_at"
+
+var planeSortOrders = catalogSortOrders("plane")
+
+var planeTable = catalogTable[Plane]{
+	table:   "planes",
+	kind:    "plane",
+	columns: planeColumns,
+	scan:    scanPlane,
+	values: func(p Plane) (string, Money, string) {
+		return p.Name, p.Price, p.Currency
+	},
+	version: func(p Plane) uint { return p.Version },
+	id:      func(p Plane) uint64 { return uint64(p.ID) },
+}
+
+type PlaneRepository struct {
+	*catalogRepository[Plane]
+}
+
+func NewPlaneRepository(db *timedDB) *PlaneRepository {
+	return &PlaneRepository{&catalogRepository[Plane]{db: db, t: planeTable}}
+}
+
+func scanPlane(row rowScanner) (Plane, error) {
+	var plane Plane
+	var deletedAt sql.NullTime
+	err := row.Scan(&plane.ID, &plane.Name, &plane.Price, &plane.Currency, &plane.Version, &plane.CreatedAt, &deletedAt)
+	if deletedAt.Valid {
+		plane.DeletedAt = &deletedAt.Time
+	}
+	return plane, err
+}
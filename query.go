@@ -0,0 +1,153 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type whereClause struct {
+	conditions []string
+	args       []interface{}
+}
+
+// add appends a condition whose single placeholder is written as $%d; the
+// placeholder is numbered after the arguments already collected.
+func (w *whereClause) add(condition string, arg interface{}) {
+	w.args = append(w.args, arg)
+	w.conditions = append(w.conditions, fmt.Sprintf(condition, len(w.args)))
+}
+
+func (w *whereClause) String() string {
+	if len(w.conditions) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(w.conditions, " AND ")
+}
+
+// escapeLike makes % and _ in user input match literally in a LIKE pattern.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+func parseIncludeDeleted(c *gin.Context) (bool, error) {
+	v := c.Query("include_deleted")
+	if v == "" {
+		return false, nil
+	}
+	includeDeleted, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, fmt.Errorf("include_deleted must be true or false")
+	}
+	return includeDeleted, nil
+}
+
+func parseStream(c *gin.Context) (bool, error) {
+	v := c.Query("stream")
+	if v == "" {
+		return false, nil
+	}
+	stream, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, fmt.Errorf("stream must be true or false")
+	}
+	return stream, nil
+}
+
+const (
+	defaultCheapest = 5
+	maxCheapest     = 50
+)
+
+// parseCheapestN reads ?n= for the cheapest endpoints, capping it at
+// maxCheapest the way parsePagination caps limit.
+func parseCheapestN(c *gin.Context) (int, error) {
+	v := c.Query("n")
+	if v == "" {
+		return defaultCheapest, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("n must be a positive integer")
+	}
+	return min(n, maxCheapest), nil
+}
+
+func parsePriceRange(c *gin.Context) (lower *Money, upper *Money, err error) {
+	if v := c.Query("min_price"); v != "" {
+		parsed, err := ParseMoney(v)
+		if err != nil {
+			return nil, nil, fmt.Errorf("min_price %s", err)
+		}
+		lower = &parsed
+	}
+
+	if v := c.Query("max_price"); v != "" {
+		parsed, err := ParseMoney(v)
+		if err != nil {
+			return nil, nil, fmt.Errorf("max_price %s", err)
+		}
+		upper = &parsed
+	}
+
+	if lower != nil && upper != nil && *lower > *upper {
+		return nil, nil, fmt.Errorf("min_price must not be greater than max_price")
+	}
+
+	return lower, upper, nil
+}
+
+// parseDateRange reads the optional RFC 3339 ?from= and ?to= bounds.
+func parseDateRange(c *gin.Context) (from *time.Time, to *time.Time, err error) {
+	if v := c.Query("from"); v != "" {
+		parsed, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return nil, nil, fmt.Errorf("from must be an RFC 3339 timestamp")
+		}
+		from = &parsed
+	}
+
+	if v := c.Query("to"); v != "" {
+		parsed, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return nil, nil, fmt.Errorf("to must be an RFC 3339 timestamp")
+		}
+		to = &parsed
+	}
+
+	if from != nil && to != nil && from.After(*to) {
+		return nil, nil, fmt.Errorf("from must not be after to")
+	}
+
+	return from, to, nil
+}
+
+// parseSort maps the ?sort= parameter onto a whitelisted ORDER BY clause so
+// the raw value never reaches the SQL string.
+func parseSort(c *gin.Context, orders map[string]string, fallback string) (string, error) {
+	sort := c.Query("sort")
+	if sort == "" {
+		return fallback, nil
+	}
+	order, ok := orders[sort]
+	if !ok {
+		return "", fmt.Errorf("unsupported sort %q", sort)
+	}
+	return order, nil
+}
+
+// parseID reads the :id path parameter. On a non-numeric or out-of-range value
+// it writes a 400 naming the resource and returns false. Ids are SERIAL, so
+// anything above the int4 maximum is out of range.
+func parseID(c *gin.Context, resource string) (uint64, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 31)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + resource + " id"})
+		return 0, false
+	}
+	return id, true
+}
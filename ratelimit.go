@@ -0,0 +1,90 @@
+package main
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/time/rate"
+)
+
+// rateLimitIdleTTL is how long a client's bucket is kept after its last
+// request before it is evicted.
+const rateLimitIdleTTL = 3 * time.Minute
+
+type clientLimiter struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+type ipRateLimiter struct {
+	mu      sync.Mutex
+	clients map[string]*clientLimiter
+	limit   rate.Limit
+	burst   int
+}
+
+func newIPRateLimiter(limit rate.Limit, burst int) *ipRateLimiter {
+	l := &ipRateLimiter{
+		clients: make(map[string]*clientLimiter),
+		limit:   limit,
+		burst:   burst,
+	}
+	go l.evictIdle()
+	return l
+}
+
+func (l *ipRateLimiter) get(ip string) *rate.Limiter {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	client, ok := l.clients[ip]
+	if !ok {
+		client = &clientLimiter{limiter: rate.NewLimiter(l.limit, l.burst)}
+		l.clients[ip] = client
+	}
+	client.lastSeen = time.Now()
+	return client.limiter
+}
+
+func (l *ipRateLimiter) evictIdle() {
+	for range time.Tick(time.Minute) {
+		l.mu.Lock()
+		for ip, client := range l.clients {
+			if time.Since(client.lastSeen) > rateLimitIdleTTL {
+				delete(l.clients, ip)
+			}
+		}
+		l.mu.Unlock()
+	}
+}
+
+// rateLimitMiddleware applies a token bucket per client IP. Paths in exempt
+// are never limited so health probes keep working under load.
+func rateLimitMiddleware(limit rate.Limit, burst int, exempt ...string) gin.HandlerFunc {
+	limiter := newIPRateLimiter(limit, burst)
+	skip := make(map[string]bool, len(exempt))
+	for _, path := range exempt {
+		skip[path] = true
+	}
+
+	return func(c *gin.Context) {
+		if skip[c.Request.URL.Path] {
+			c.Next()
+			return
+		}
+
+		reservation := limiter.get(c.ClientIP()).Reserve()
+		if delay := reservation.Delay(); delay > 0 {
+			reservation.Cancel()
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(delay.Seconds()))))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "Too many requests"})
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,20 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// readOnlyMiddleware turns away every write with a 503 so the API can keep
+// serving reads during maintenance.
+func readOnlyMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "service is in read-only mode"})
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"syscall"
+
+	"github.com/gin-gonic/gin"
+)
+
+// recoveryMiddleware replaces gin.Recovery so panics come back in the same
+// JSON error shape as everything else, tagged with the request id.
+func recoveryMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			rec := recover()
+			if rec == nil {
+				return
+			}
+
+			// A client that went away mid-response can't be told anything.
+			if err, ok := rec.(error); ok && (errors.Is(err, syscall.EPIPE) || errors.Is(err, syscall.ECONNRESET)) {
+				c.Abort()
+				return
+			}
+
+			slog.Error("panic recovered",
+				"request_id", c.GetString(requestIDKey),
+				"method", c.Request.Method,
+				"path", c.Request.URL.Path,
+				"panic", rec,
+				"stack", string(debug.Stack()),
+			)
+
+			if c.Writer.Written() {
+				c.Abort()
+				return
+			}
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+				"error":      "internal server error",
+				"request_id": c.GetString(requestIDKey),
+			})
+		}()
+		c.Next()
+	}
+}
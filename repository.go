@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"math"
+)
+
+// ErrNotFound is returned by repository methods when no row matches the
+// requested id.
+var ErrNotFound = errors.New("record not found")
+
+// ErrVersionConflict is returned by updates whose expected version no longer
+// matches the stored row.
+var ErrVersionConflict = errors.New("version conflict")
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// checkRowsAffected turns a write that matched nothing into ErrNotFound.
+func checkRowsAffected(result sql.Result) error {
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// PriceStats summarises the prices in a table. Min, Max and Avg are nil when
+// the table is empty; Avg is rounded to the nearest minor unit.
+type PriceStats struct {
+	Count int    `json:"count"`
+	Min   *Money `json:"min_price" swaggertype:"number"`
+	Max   *Money `json:"max_price" swaggertype:"number"`
+	Avg   *Money `json:"avg_price" swaggertype:"number"`
+	Total Money  `json:"total_price" swaggertype:"number"`
+}
+
+func scanPriceStats(row rowScanner) (PriceStats, error) {
+	var stats PriceStats
+	var min, max sql.NullInt64
+	var avg sql.NullFloat64
+	if err := row.Scan(&stats.Count, &min, &max, &avg, &stats.Total); err != nil {
+		return PriceStats{}, err
+	}
+	if min.Valid {
+		m := Money(min.Int64)
+		stats.Min = &m
+	}
+	if max.Valid {
+		m := Money(max.Int64)
+		stats.Max = &m
+	}
+	if avg.Valid {
+		m := Money(math.Round(avg.Float64))
+		stats.Avg = &m
+	}
+	return stats, nil
+}
+
+func queryPrices(ctx context.Context, db *timedDB, query string) ([]Money, error) {
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	prices := []Money{}
+	for rows.Next() {
+		var price Money
+		if err := rows.Scan(&price); err != nil {
+			return nil, err
+		}
+		prices = append(prices, price)
+	}
+	return prices, rows.Err()
+}
+
+func scanIDs(rows *sql.Rows) ([]int64, error) {
+	defer rows.Close()
+
+	ids := []int64{}
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
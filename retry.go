@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"log/slog"
+	"syscall"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// readRetries is how many extra attempts a read query gets after a transient
+// failure, waiting readRetryBackoff and then doubling between attempts.
+var (
+	readRetries      = 2
+	readRetryBackoff = 100 * time.Millisecond
+)
+
+// isTransient reports whether err is the kind of failure a retry can fix,
+// such as a connection dropped during a failover or a serialization failure.
+// Query timeouts and constraint violations are not.
+func isTransient(err error) bool {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && (pqErr.Code == "40001" || pqErr.Code == "40P01") {
+		return true
+	}
+	return isConnectionLost(err)
+}
+
+// isConnectionLost reports whether err means the connection to the database
+// failed or the server is shutting down or not yet accepting connections.
+func isConnectionLost(err error) bool {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code {
+		case "57P01", "57P02", "57P03":
+			return true
+		}
+		return pqErr.Code.Class() == "08"
+	}
+	return errors.Is(err, driver.ErrBadConn) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED)
+}
+
+// retryRead runs a read-only query, retrying transient failures with
+// exponential backoff. Writes must not go through it unless they are
+// idempotent.
+func retryRead(ctx context.Context, op func() error) error {
+	backoff := readRetryBackoff
+	err := op()
+	for attempt := 1; attempt <= readRetries && err != nil && isTransient(err); attempt++ {
+		slog.Warn("retrying read after transient database error", "attempt", attempt, "error", err)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		err = op()
+	}
+	return err
+}
+
+// retryReadValue is retryRead for queries that produce a single value.
+func retryReadValue[V any](ctx context.Context, op func() (V, error)) (V, error) {
+	var v V
+	err := retryRead(ctx, func() error {
+		var err error
+		v, err = op()
+		return err
+	})
+	return v, err
+}
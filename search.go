@@ -0,0 +1,102 @@
+package main
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SearchResult struct {
+	Type      string    `json:"type"`
+	ID        uint      `json:"id"`
+	Name      string    `json:"name"`
+	Price     Money     `json:"price" swaggertype:"number"`
+	Currency  string    `json:"currency"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// SearchPage is the /search response. Each type is capped at maxPageLimit
+// matches; Totals counts every match and Truncated reports that some were
+// left out.
+type SearchPage struct {
+	Data      []SearchResult `json:"data"`
+	Totals    SearchTotals   `json:"totals"`
+	Truncated bool           `json:"truncated"`
+}
+
+type SearchTotals struct {
+	Trains int `json:"trains"`
+	Planes int `json:"planes"`
+}
+
+// searchAll looks up matching trains and planes concurrently and returns them
+// as one list ordered by price. Without q every train and plane matches.
+//
+// @Summary	Search trains and planes
+// @Tags	search
+// @Produce	json
+// @Param	q	query	string	false	"Name substring"
+// @Param	min_price	query	number	false	"Lowest price"
+// @Param	max_price	query	number	false	"Highest price"
+// @Success	200	{object}	SearchPage
+// @Failure	400	{object}	errorResponse
+// @Failure	500	{object}	errorResponse
+// @Router	/search [get]
+func searchAll(c *gin.Context) {
+	lower, upper, err := parsePriceRange(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	q := c.Query("q")
+
+	ctx, cancel := dbContext(c)
+	defer cancel()
+
+	var (
+		wg                     sync.WaitGroup
+		trains                 []Train
+		planes                 []Plane
+		trainTotal, planeTotal int
+		trainErr, planeErr     error
+	)
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		trains, trainTotal, trainErr = trainRepo.List(ctx, CatalogFilter{MinPrice: lower, MaxPrice: upper, Search: q, Limit: maxPageLimit})
+	}()
+	go func() {
+		defer wg.Done()
+		planes, planeTotal, planeErr = planeRepo.List(ctx, CatalogFilter{MinPrice: lower, MaxPrice: upper, Search: q, Limit: maxPageLimit})
+	}()
+	wg.Wait()
+
+	if trainErr != nil {
+		handleDBError(c, trainErr)
+		return
+	}
+	if planeErr != nil {
+		handleDBError(c, planeErr)
+		return
+	}
+
+	results := make([]SearchResult, 0, len(trains)+len(planes))
+	for _, train := range trains {
+		results = append(results, SearchResult{Type: "train", ID: train.ID, Name: train.Name, Price: train.Price, Currency: train.Currency, CreatedAt: train.CreatedAt})
+	}
+	for _, plane := range planes {
+		results = append(results, SearchResult{Type: "plane", ID: plane.ID, Name: plane.Name, Price: plane.Price, Currency: plane.Currency, CreatedAt: plane.CreatedAt})
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Price < results[j].Price
+	})
+
+	c.JSON(http.StatusOK, SearchPage{
+		Data:      results,
+		Totals:    SearchTotals{Trains: trainTotal, Planes: planeTotal},
+		Truncated: trainTotal > len(trains) || planeTotal > len(planes),
+	})
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"log"
+)
+
+var seedTrains = []Train{
+	{Name: "Tashkent - Samarkand", Price: 120_00},
+	{Name: "Tashkent - Bukhara", Price: 180_00},
+	{Name: "Samarkand - Khiva", Price: 210_00},
+	{Name: "Tashkent - Fergana", Price: 95_00},
+}
+
+var seedPlanes = []Plane{
+	{Name: "Tashkent - Istanbul", Price: 450_00},
+	{Name: "Tashkent - Dubai", Price: 380_00},
+	{Name: "Samarkand - Moscow", Price: 320_00},
+	{Name: "Tashkent - Seoul", Price: 610_00},
+}
+
+var seedHistory = []History{
+	{Name: "Tashkent - Samarkand", Price: 120_00},
+	{Name: "Tashkent - Dubai", Price: 380_00},
+}
+
+// seedDatabase fills empty tables with sample rows. Tables that already hold
+// data are left alone so running it twice is harmless.
+func seedDatabase(ctx context.Context) error {
+	if n, err := trainRepo.Count(ctx); err != nil {
+		return err
+	} else if n > 0 {
+		log.Printf("Seed: trains already has %d rows, skipping", n)
+	} else {
+		inserted, err := trainRepo.InsertBatch(ctx, seedTrains)
+		if err != nil {
+			return err
+		}
+		log.Printf("Seed: inserted %d trains", inserted)
+	}
+
+	if n, err := planeRepo.Count(ctx); err != nil {
+		return err
+	} else if n > 0 {
+		log.Printf("Seed: planes already has %d rows, skipping", n)
+	} else {
+		for _, plane := range seedPlanes {
+			if _, err := planeRepo.Insert(ctx, plane); err != nil {
+				return err
+			}
+		}
+		log.Printf("Seed: inserted %d planes", len(seedPlanes))
+	}
+
+	if n, err := historyRepo.Count(ctx); err != nil {
+		return err
+	} else if n > 0 {
+		log.Printf("Seed: history already has %d rows, skipping", n)
+	} else {
+		for _, history := range seedHistory {
+			if _, err := historyRepo.Insert(ctx, history); err != nil {
+				return err
+			}
+		}
+		log.Printf("Seed: inserted %d history entries", len(seedHistory))
+	}
+
+	return nil
+}
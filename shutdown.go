@@ -0,0 +1,24 @@
+package main
+
+import (
+	"net/http"
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+)
+
+// shuttingDown is set once the server starts draining. Requests that arrive
+// on kept-alive connections after that are refused instead of reaching a pool
+// that is about to close.
+var shuttingDown atomic.Bool
+
+func shutdownGuard() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if shuttingDown.Load() {
+			c.Header("Connection", "close")
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "shutting down"})
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// slowQueryThreshold is how long a statement may take before it is logged;
+// zero turns the log off.
+var slowQueryThreshold = 200 * time.Millisecond
+
+// timedDB wraps the pool so every statement the repositories run is timed
+// and its outcome fed to dbBreaker.
+// Statements on transactions from BeginTx are timed too. Migrations use a
+// dedicated *sql.Conn and are not.
+type timedDB struct {
+	*sql.DB
+}
+
+func (db *timedDB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	start := time.Now()
+	rows, err := db.DB.QueryContext(ctx, query, args...)
+	logIfSlow(start, query)
+	dbBreaker.record(err)
+	return rows, err
+}
+
+func (db *timedDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	start := time.Now()
+	row := db.DB.QueryRowContext(ctx, query, args...)
+	logIfSlow(start, query)
+	dbBreaker.record(row.Err())
+	return row
+}
+
+func (db *timedDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	start := time.Now()
+	result, err := db.DB.ExecContext(ctx, query, args...)
+	logIfSlow(start, query)
+	dbBreaker.record(err)
+	return result, err
+}
+
+func (db *timedDB) BeginTx(ctx context.Context, opts *sql.TxOptions) (*timedTx, error) {
+	tx, err := db.DB.BeginTx(ctx, opts)
+	dbBreaker.record(err)
+	if err != nil {
+		return nil, err
+	}
+	return &timedTx{tx}, nil
+}
+
+type timedTx struct {
+	*sql.Tx
+}
+
+func (tx *timedTx) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	start := time.Now()
+	rows, err := tx.Tx.QueryContext(ctx, query, args...)
+	logIfSlow(start, query)
+	dbBreaker.record(err)
+	return rows, err
+}
+
+func (tx *timedTx) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	start := time.Now()
+	row := tx.Tx.QueryRowContext(ctx, query, args...)
+	logIfSlow(start, query)
+	dbBreaker.record(row.Err())
+	return row
+}
+
+func (tx *timedTx) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	start := time.Now()
+	result, err := tx.Tx.ExecContext(ctx, query, args...)
+	logIfSlow(start, query)
+	dbBreaker.record(err)
+	return result, err
+}
+
+// logIfSlow warns about a statement that ran past slowQueryThreshold. The
+// query is named after the repository function that issued it; looking that
+// up only happens on the slow path.
+func logIfSlow(start time.Time, query string) {
+	elapsed := time.Since(start)
+	if slowQueryThreshold == 0 || elapsed < slowQueryThreshold {
+		return
+	}
+	slog.Warn("slow query", "name", queryCaller(), "duration_ms", elapsed.Milliseconds(), "query", strings.Join(strings.Fields(query), " "))
+}
+
+// queryCaller names the function that called the timedDB or timedTx method,
+// e.g. "(*catalogRepository[...]).GetByID". Closures such as those passed to
+// retryRead are reported as the function that declared them.
+func queryCaller() string {
+	pc := make([]uintptr, 1)
+	// Skip runtime.Callers, queryCaller, logIfSlow and the wrapper method.
+	if runtime.Callers(4, pc) == 0 {
+		return "unknown"
+	}
+	frame, _ := runtime.CallersFrames(pc).Next()
+	name := frame.Function[strings.LastIndex(frame.Function, "/")+1:]
+	_, name, _ = strings.Cut(name, ".")
+	if i := strings.Index(name, ".func"); i > 0 {
+		name = name[:i]
+	}
+	return name
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const serviceName = "DB-project API"
+
+var startedAt = time.Now()
+
+// dbStatus is the result of the last background ping, so the home page can
+// show it without touching the database on every hit.
+var dbStatus struct {
+	sync.RWMutex
+	ok        bool
+	checkedAt time.Time
+}
+
+// watchDatabase pings the database every interval until the server starts
+// shutting down.
+func watchDatabase(interval time.Duration) {
+	for ; !shuttingDown.Load(); time.Sleep(interval) {
+		ctx, cancel := context.WithTimeout(context.Background(), healthTimeout)
+		err := db.PingContext(ctx)
+		cancel()
+
+		dbStatus.Lock()
+		dbStatus.ok = err == nil
+		dbStatus.checkedAt = time.Now()
+		dbStatus.Unlock()
+	}
+}
+
+func homePage(c *gin.Context) {
+	dbStatus.RLock()
+	database := "unavailable"
+	if dbStatus.ok {
+		database = "ok"
+	}
+	checkedAt := dbStatus.checkedAt
+	dbStatus.RUnlock()
+
+	c.JSON(http.StatusOK, gin.H{
+		"service":             serviceName,
+		"uptime":              time.Since(startedAt).Round(time.Second).String(),
+		"database":            database,
+		"database_checked_at": checkedAt,
+	})
+}
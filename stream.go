@@ -0,0 +1,73 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// streamFlushEvery controls how many JSON elements are written between
+// flushes to the client.
+const streamFlushEvery = 100
+
+// streamJSON writes rows as a JSON array while they are scanned so memory
+// stays flat however many rows match. Like streamCSV, a failure after the
+// first byte can't change the status; it is logged and the array is left
+// unterminated so clients see an invalid document rather than a short list.
+// A non-nil fields trims each element as ?fields= does.
+func streamJSON[T any](c *gin.Context, rows *sql.Rows, scan func(rowScanner) (T, error), fields []string) {
+	defer rows.Close()
+
+	c.Header("Content-Type", "application/json; charset=utf-8")
+	c.Status(http.StatusOK)
+
+	fail := func(written int, err error) {
+		slog.Error("JSON stream failed", "request_id", c.GetString(requestIDKey), "path", c.Request.URL.Path, "rows", written, "error", err)
+	}
+
+	if _, err := c.Writer.WriteString("["); err != nil {
+		fail(0, err)
+		return
+	}
+	var pick func(T) map[string]interface{}
+	if fields != nil {
+		pick = fieldPicker[T](fields)
+	}
+	enc := json.NewEncoder(c.Writer)
+	written := 0
+	for rows.Next() {
+		item, err := scan(rows)
+		if err != nil {
+			fail(written, err)
+			return
+		}
+		if written > 0 {
+			if _, err := c.Writer.WriteString(","); err != nil {
+				fail(written, err)
+				return
+			}
+		}
+		var element interface{} = item
+		if pick != nil {
+			element = pick(item)
+		}
+		if err := enc.Encode(element); err != nil {
+			fail(written, err)
+			return
+		}
+		written++
+		if written%streamFlushEvery == 0 {
+			c.Writer.Flush()
+		}
+	}
+	if err := rows.Err(); err != nil {
+		fail(written, err)
+		return
+	}
+	if _, err := c.Writer.WriteString("]"); err != nil {
+		fail(written, err)
+	}
+}
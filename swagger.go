@@ -0,0 +1,54 @@
+// @title						DB-project API
+// @version					1.0
+// @description				Trains, planes and the history of purchases made from them.
+// @securityDefinitions.apikey	BearerAuth
+// @in							header
+// @name						Authorization
+// @description				"Bearer <JWT>"; deletes need the admin role.
+
+package main
+
+// The types below only describe response bodies that the handlers build with
+// gin.H, so the generated spec has something to point at. Regenerate docs/
+// with `swag init -g swagger.go` after changing a handler or struct.
+
+type errorResponse struct {
+	Error string `json:"error" example:"Train not found"`
+}
+
+type validationErrorResponse struct {
+	Errors map[string]string `json:"errors"`
+}
+
+// versionConflictResponse is returned when a PUT carries a stale version.
+type versionConflictResponse struct {
+	Error   string      `json:"error"`
+	Current interface{} `json:"current,omitempty"`
+}
+
+type messageResponse struct {
+	Message string `json:"message" example:"Train deleted successfully"`
+}
+
+type countResponse struct {
+	Count int `json:"count"`
+}
+
+type bookingResponse struct {
+	Booking Booking `json:"booking"`
+	History History `json:"history"`
+}
+
+type bulkInsertResponse struct {
+	Inserted int64 `json:"inserted"`
+}
+
+type batchDeleteResponse struct {
+	Deleted  int     `json:"deleted"`
+	NotFound []int64 `json:"not_found"`
+}
+
+type clearHistoryResponse struct {
+	Message string `json:"message" example:"History cleared"`
+	Removed int    `json:"removed"`
+}
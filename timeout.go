@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const baseContextKey = "base_context"
+
+// requestTimeout puts a deadline on the whole request. Handlers are not
+// interrupted, but every database call derives its context from the request
+// so it is cancelled once the deadline passes, and if the handler has not
+// written a response by then the client gets a 503.
+func requestTimeout(timeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		base := c.Request.Context()
+		c.Set(baseContextKey, base)
+
+		ctx, cancel := context.WithTimeout(base, timeout)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		if errors.Is(c.Request.Context().Err(), context.DeadlineExceeded) && !c.Writer.Written() {
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "Request timed out"})
+		}
+	}
+}
+
+// overrideTimeout replaces the deadline set by requestTimeout for the routes
+// it is attached to, e.g. to give exports longer than ordinary requests.
+func overrideTimeout(timeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		base, ok := c.Get(baseContextKey)
+		if !ok {
+			c.Next()
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(base.(context.Context), timeout)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+	}
+}
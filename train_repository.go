@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const trainColumns = "train_id, train_name, train_price, currency, version, created_at, deleted_at"
+
+var trainSortOrders = catalogSortOrders("train")
+
+var trainTable = catalogTable[Train]{
+	table:   "trains",
+	kind:    "train",
+	columns: trainColumns,
+	scan:    scanTrain,
+	values: func(t Train) (string, Money, string) {
+		return t.Name, t.Price, t.Currency
+	},
+	version: func(t Train) uint { return t.Version },
+	id:      func(t Train) uint64 { return uint64(t.ID) },
+}
+
+// TrainPatch holds the fields of a partial update; nil means "leave as is".
+type TrainPatch struct {
+	Name     *string `json:"train_name" binding:"omitempty,notblank"`
+	Price    *Money  `json:"train_price" swaggertype:"number" example:"19.99"`
+	Currency *string `json:"currency" binding:"omitempty,currency"`
+}
+
+// TrainRepository adds the train-only bulk insert and partial update to the
+// shared catalog queries.
+type TrainRepository struct {
+	*catalogRepository[Train]
+}
+
+func NewTrainRepository(db *timedDB) *TrainRepository {
+	return &TrainRepository{&catalogRepository[Train]{db: db, t: trainTable}}
+}
+
+func scanTrain(row rowScanner) (Train, error) {
+	var train Train
+	var deletedAt sql.NullTime
+	err := row.Scan(&train.ID, &train.Name, &train.Price, &train.Currency, &train.Version, &train.CreatedAt, &deletedAt)
+	if deletedAt.Valid {
+		train.DeletedAt = &deletedAt.Time
+	}
+	return train, err
+}
+
+// InsertBatch inserts all trains with a single multi-row INSERT inside a
+// transaction and returns the number of rows written.
+func (r *TrainRepository) InsertBatch(ctx context.Context, trains []Train) (int64, error) {
+	placeholders := make([]string, 0, len(trains))
+	args := make([]interface{}, 0, len(trains)*3)
+	for _, train := range trains {
+		placeholders = append(placeholders, fmt.Sprintf("($%d, $%d, $%d)", len(args)+1, len(args)+2, len(args)+3))
+		args = append(args, train.Name, train.Price, currencyOrBase(train.Currency))
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, "INSERT INTO trains (train_name, train_price, currency) VALUES "+strings.Join(placeholders, ", "), args...)
+	if err != nil {
+		return 0, err
+	}
+
+	inserted, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return inserted, tx.Commit()
+}
+
+// ImportResult counts what Import did with each train. Trains that already
+// matched the stored row are unchanged.
+type ImportResult struct {
+	Inserted  int `json:"inserted"`
+	Updated   int `json:"updated"`
+	Unchanged int `json:"unchanged"`
+}
+
+// Import upserts trains by name inside a single transaction, so either every
+// row is applied or none is. Rows are written one at a time so a name that
+// appears twice in trains simply updates the row the first one wrote. Only
+// live trains are matched, as the unique name index covers only those; a
+// name that belongs to a soft-deleted train creates a new one.
+func (r *TrainRepository) Import(ctx context.Context, trains []Train) (ImportResult, error) {
+	var result ImportResult
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return result, err
+	}
+	defer tx.Rollback()
+
+	// xmax is 0 only on a freshly inserted row version. The WHERE skips the
+	// update, and so returns no row, when nothing would change.
+	const query = `INSERT INTO trains (train_name, train_price, currency) VALUES ($1, $2, $3)
+		ON CONFLICT (train_name) WHERE deleted_at IS NULL DO UPDATE
+		SET train_price = EXCLUDED.train_price, currency = EXCLUDED.currency, version = trains.version + 1
+		WHERE (trains.train_price, trains.currency) IS DISTINCT FROM (EXCLUDED.train_price, EXCLUDED.currency)
+		RETURNING xmax = 0`
+	for _, train := range trains {
+		var inserted bool
+		err := tx.QueryRowContext(ctx, query, train.Name, train.Price, currencyOrBase(train.Currency)).Scan(&inserted)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			result.Unchanged++
+		case err != nil:
+			return ImportResult{}, err
+		case inserted:
+			result.Inserted++
+		default:
+			result.Updated++
+		}
+	}
+	return result, tx.Commit()
+}
+
+// Patch updates only the fields set in patch, which must contain at least one.
+func (r *TrainRepository) Patch(ctx context.Context, id uint64, patch TrainPatch) (Train, error) {
+	var sets []string
+	var args []interface{}
+	if patch.Name != nil {
+		args = append(args, *patch.Name)
+		sets = append(sets, fmt.Sprintf("train_name=$%d", len(args)))
+	}
+	if patch.Price != nil {
+		args = append(args, *patch.Price)
+		sets = append(sets, fmt.Sprintf("train_price=$%d", len(args)))
+	}
+	if patch.Currency != nil {
+		args = append(args, *patch.Currency)
+		sets = append(sets, fmt.Sprintf("currency=$%d", len(args)))
+	}
+	sets = append(sets, "version=version+1")
+	args = append(args, id)
+
+	query := fmt.Sprintf("UPDATE trains SET %s WHERE train_id=$%d AND deleted_at IS NULL RETURNING %s", strings.Join(sets, ", "), len(args), trainColumns)
+	return r.one(r.db.QueryRowContext(ctx, query, args...))
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+const txKey = "tx"
+
+// dbtx is satisfied by both the pool and a transaction, so a repository can
+// run the same queries on either.
+type dbtx interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// transactional runs the rest of the chain inside one database transaction,
+// which handlers get from txFrom. It commits when the handler answers 2xx
+// and rolls back on anything else, including a panic. The response is held
+// back until the commit succeeds, so a failed commit still reaches the
+// client as an error rather than after a success status.
+func transactional() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tx, err := db.BeginTx(c.Request.Context(), nil)
+		if err != nil {
+			handleDBError(c, err)
+			c.Abort()
+			return
+		}
+		defer tx.Rollback()
+
+		original := c.Writer
+		buffered := &bufferedWriter{ResponseWriter: original}
+		c.Writer = buffered
+		defer func() { c.Writer = original }()
+
+		c.Set(txKey, tx)
+		c.Next()
+		c.Writer = original
+
+		status := buffered.Status()
+		if status >= 200 && status < 300 && len(c.Errors) == 0 {
+			if err := tx.Commit(); err != nil {
+				handleDBError(c, err)
+				return
+			}
+		}
+		original.WriteHeader(status)
+		original.Write(buffered.body.Bytes())
+	}
+}
+
+// txFrom returns the transaction begun by transactional. It panics when the
+// route was not wrapped, which is a wiring mistake rather than a runtime
+// condition.
+func txFrom(c *gin.Context) *timedTx {
+	return c.MustGet(txKey).(*timedTx)
+}
+
+// bufferedWriter keeps the status and body in memory instead of sending
+// them; headers go straight to the wrapped writer's map.
+type bufferedWriter struct {
+	gin.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (w *bufferedWriter) WriteHeader(code int) {
+	w.status = code
+}
+
+func (w *bufferedWriter) WriteHeaderNow() {}
+
+func (w *bufferedWriter) Write(b []byte) (int, error) {
+	return w.body.Write(b)
+}
+
+func (w *bufferedWriter) WriteString(s string) (int, error) {
+	return w.body.WriteString(s)
+}
+
+func (w *bufferedWriter) Status() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+func (w *bufferedWriter) Size() int {
+	return w.body.Len()
+}
+
+func (w *bufferedWriter) Written() bool {
+	return w.status != 0 || w.body.Len() > 0
+}
+
+func (w *bufferedWriter) Flush() {}
@@ -0,0 +1,281 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"reflect"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// fieldErrors maps a JSON field name to what is wrong with it.
+type fieldErrors map[string]string
+
+// validatable is implemented by request types with rules that struct tags
+// can't express, such as "at least one of these fields".
+type validatable interface {
+	validate() fieldErrors
+}
+
+func (p TrainPatch) validate() fieldErrors {
+	errs := ruleFor("train").check("train_name", p.Name, "train_price", p.Price)
+	if p.Name == nil && p.Price == nil && p.Currency == nil {
+		errs["body"] = "at least one of train_name, train_price or currency is required"
+	}
+	return errs
+}
+
+func (t Train) validate() fieldErrors {
+	return ruleFor("train").check("train_name", &t.Name, "train_price", &t.Price)
+}
+
+func (p Plane) validate() fieldErrors {
+	return ruleFor("plane").check("plane_name", &p.Name, "plane_price", &p.Price)
+}
+
+func (b Bus) validate() fieldErrors {
+	return ruleFor("bus").check("bus_name", &b.Name, "bus_price", &b.Price)
+}
+
+func (h History) validate() fieldErrors {
+	return ruleFor("history").check("history_name", &h.Name, "history_price", &h.Price)
+}
+
+// maxNameColumn is the width of the VARCHAR(100) name columns, which stay in
+// place as a backstop; no rule may allow longer names.
+const maxNameColumn = 100
+
+// validationRule bounds the name and price of one resource. Train, Plane, Bus
+// and History check theirs in their validate methods rather than in binding
+// tags, so the limits can be tuned per resource at startup. A zero MaxPrice
+// leaves the price unbounded above.
+type validationRule struct {
+	MinNameLength int
+	MaxNameLength int
+	MinPrice      Money
+	MaxPrice      Money
+}
+
+// validationRules maps a resource kind to its rule. loadValidationRules fills
+// it at startup; kinds it doesn't list get defaultValidationRule.
+var validationRules = map[string]validationRule{}
+
+func defaultValidationRule() validationRule {
+	return validationRule{MinNameLength: 1, MaxNameLength: maxNameColumn, MinPrice: minPrice}
+}
+
+func ruleFor(kind string) validationRule {
+	if rule, ok := validationRules[kind]; ok {
+		return rule
+	}
+	return defaultValidationRule()
+}
+
+// loadValidationRules reads each resource's rule from <PREFIX>_NAME_MIN_LENGTH,
+// <PREFIX>_NAME_MAX_LENGTH, <PREFIX>_MIN_PRICE and <PREFIX>_MAX_PRICE, where
+// prefixes maps a kind to its prefix, e.g. "train" to "TRAINS". Unset
+// variables keep the defaults: 1 to 100 characters and MIN_PRICE upwards. It
+// exits on a rule that can never be met or that the columns can't store.
+func loadValidationRules(prefixes map[string]string) {
+	fallback := defaultValidationRule()
+	for kind, prefix := range prefixes {
+		rule := validationRule{
+			MinNameLength: envInt(prefix+"_NAME_MIN_LENGTH", fallback.MinNameLength),
+			MaxNameLength: envInt(prefix+"_NAME_MAX_LENGTH", fallback.MaxNameLength),
+			MinPrice:      envMoney(prefix+"_MIN_PRICE", fallback.MinPrice),
+			MaxPrice:      envMoney(prefix+"_MAX_PRICE", fallback.MaxPrice),
+		}
+		switch {
+		case rule.MaxNameLength == 0 || rule.MaxNameLength > maxNameColumn:
+			log.Fatalf("Invalid %s_NAME_MAX_LENGTH %d: must be between 1 and %d, the width of the name column", prefix, rule.MaxNameLength, maxNameColumn)
+		case rule.MinNameLength > rule.MaxNameLength:
+			log.Fatalf("Invalid %s_NAME_MIN_LENGTH %d: must not exceed the maximum of %d", prefix, rule.MinNameLength, rule.MaxNameLength)
+		case rule.MinPrice == 0:
+			log.Fatalf("Invalid %s_MIN_PRICE: must be positive", prefix)
+		case rule.MaxPrice != 0 && rule.MaxPrice < rule.MinPrice:
+			log.Fatalf("Invalid %s_MAX_PRICE %s: must not be below the minimum of %s", prefix, rule.MaxPrice, rule.MinPrice)
+		}
+		validationRules[kind] = rule
+	}
+}
+
+// check applies the rule to a name and price, either of which may be nil
+// when a patch leaves it out. Lengths count characters, as VARCHAR does.
+func (r validationRule) check(nameField string, name *string, priceField string, price *Money) fieldErrors {
+	errs := fieldErrors{}
+	if name != nil {
+		switch n := utf8.RuneCountInString(*name); {
+		case n > r.MaxNameLength:
+			errs[nameField] = fmt.Sprintf("%s must be at most %d characters", nameField, r.MaxNameLength)
+		case n < r.MinNameLength:
+			errs[nameField] = fmt.Sprintf("%s must be at least %d characters", nameField, r.MinNameLength)
+		}
+	}
+	if price != nil {
+		switch {
+		case *price < r.MinPrice:
+			errs[priceField] = fmt.Sprintf("%s must be at least %s", priceField, r.MinPrice)
+		case r.MaxPrice != 0 && *price > r.MaxPrice:
+			errs[priceField] = fmt.Sprintf("%s must be at most %s", priceField, r.MaxPrice)
+		}
+	}
+	return errs
+}
+
+// registerValidators teaches gin's validator the custom tags used in binding
+// struct tags and makes it report fields by their JSON names.
+func registerValidators() error {
+	v, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return errors.New("unexpected validator engine")
+	}
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+	if err := v.RegisterValidation("notblank", func(fl validator.FieldLevel) bool {
+		return strings.TrimSpace(fl.Field().String()) != ""
+	}); err != nil {
+		return err
+	}
+	return v.RegisterValidation("currency", func(fl validator.FieldLevel) bool {
+		return supportedCurrencies[fl.Field().String()]
+	})
+}
+
+// bindAndValidate decodes the JSON body into obj, letting gin run the binding
+// tags, then applies any validate method. On failure it writes a 400 with a
+// field-keyed "errors" object (or a 413 for an oversized body) and returns
+// false.
+func bindAndValidate(c *gin.Context, obj interface{}) bool {
+	err := c.ShouldBindWith(obj, binding.JSON)
+	var validationErrs validator.ValidationErrors
+	if err != nil && !errors.As(err, &validationErrs) {
+		respondBindError(c, err)
+		return false
+	}
+	if errs := checkItem(obj, err); len(errs) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"errors": errs})
+		return false
+	}
+	return true
+}
+
+// validateItem runs the binding tags and any validate method on a value
+// decoded without gin, such as one element of a bulk request.
+func validateItem(obj interface{}) fieldErrors {
+	return checkItem(obj, binding.Validator.ValidateStruct(obj))
+}
+
+// checkItem merges the binding tag failures in tagErr with obj's validate
+// method, keeping the tag's message when both flag a field, so every bad
+// field is reported at once.
+func checkItem(obj interface{}, tagErr error) fieldErrors {
+	errs := fieldErrors{}
+	if tagErr != nil {
+		errs = bindErrors(tagErr)
+	}
+	if v, ok := obj.(validatable); ok {
+		for field, message := range v.validate() {
+			if _, seen := errs[field]; !seen {
+				errs[field] = message
+			}
+		}
+	}
+	return errs
+}
+
+// bindErrors turns a JSON decoding or validation error into messages keyed by
+// the offending field instead of leaking Go type names to the client.
+func bindErrors(err error) fieldErrors {
+	var typeErr *json.UnmarshalTypeError
+	var syntaxErr *json.SyntaxError
+	var fieldErr *fieldError
+	var validationErrs validator.ValidationErrors
+	switch {
+	case errors.As(err, &validationErrs):
+		return translateValidation(validationErrs)
+	case errors.As(err, &fieldErr):
+		return fieldErrors{fieldErr.field: fieldErr.message}
+	case errors.As(err, &typeErr) && typeErr.Field != "":
+		return fieldErrors{typeErr.Field: describeType(typeErr.Type)}
+	case errors.As(err, &syntaxErr), errors.Is(err, io.ErrUnexpectedEOF):
+		return fieldErrors{"body": "must be valid JSON"}
+	case errors.Is(err, io.EOF):
+		return fieldErrors{"body": "must not be empty"}
+	case errors.As(err, &typeErr):
+		return fieldErrors{"body": describeType(typeErr.Type)}
+	}
+	return fieldErrors{"body": "could not be parsed"}
+}
+
+// translateValidation renders validator failures in the same words the
+// handlers have always used.
+func translateValidation(validationErrs validator.ValidationErrors) fieldErrors {
+	errs := fieldErrors{}
+	for _, fe := range validationErrs {
+		field := fe.Field()
+		var message string
+		switch fe.Tag() {
+		case "required":
+			message = fmt.Sprintf("%s is required", field)
+		case "notblank":
+			message = fmt.Sprintf("%s must not be empty", field)
+		case "currency":
+			message = fmt.Sprintf("%s must be one of %s", field, supportedCurrencyList())
+		case "max":
+			if fe.Kind() == reflect.String {
+				message = fmt.Sprintf("%s must be at most %s characters", field, fe.Param())
+			} else {
+				message = fmt.Sprintf("%s must contain at most %s items", field, fe.Param())
+			}
+		case "min":
+			message = fmt.Sprintf("%s must not be empty", field)
+			if fe.Param() != "1" {
+				message = fmt.Sprintf("%s must contain at least %s items", field, fe.Param())
+			}
+		case "gt":
+			message = fmt.Sprintf("%s must be greater than %s", field, fe.Param())
+		case "lte":
+			message = fmt.Sprintf("%s must be at most %s", field, fe.Param())
+		default:
+			message = fmt.Sprintf("%s is invalid", field)
+		}
+		if _, seen := errs[field]; !seen {
+			errs[field] = message
+		}
+	}
+	return errs
+}
+
+func describeType(t reflect.Type) string {
+	if t == reflect.TypeOf(Money(0)) {
+		return errInvalidMoney.Error()
+	}
+	switch t.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "must be a non-negative integer"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return "must be an integer"
+	case reflect.String:
+		return "must be a string"
+	case reflect.Bool:
+		return "must be a boolean"
+	case reflect.Slice, reflect.Array:
+		return "must be an array"
+	case reflect.Struct, reflect.Map:
+		return "must be an object"
+	}
+	return fmt.Sprintf("must be a valid %s", t.Kind())
+}
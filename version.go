@@ -0,0 +1,20 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Build information, set at build time with
+//
+//	go build -ldflags "-X main.version=1.2.3 -X main.commit=$(git rev-parse HEAD) -X main.built=$(date -u +%FT%TZ)"
+var (
+	version = "dev"
+	commit  = "unknown"
+	built   = "unknown"
+)
+
+func versionInfo(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"version": version, "commit": commit, "built": built})
+}